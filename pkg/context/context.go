@@ -20,7 +20,9 @@ package context
 
 import (
 	"bytes"
+	stdcontext "context"
 	"runtime/debug"
+	"time"
 
 	"github.com/megaease/easegress/v2/pkg/logger"
 	"github.com/megaease/easegress/v2/pkg/protocols"
@@ -339,6 +341,39 @@ func (ctx *Context) SetInputResponse(resp protocols.Response) {
 	ctx.SetResponse(ctx.activeNs, resp)
 }
 
+// stdContext returns the standard context of the request in the default
+// namespace, that's, the request from the client. It returns nil if there's
+// no such request or the request does not carry a standard context.
+func (ctx *Context) stdContext() stdcontext.Context {
+	req, ok := ctx.GetRequest(DefaultNamespace).(interface {
+		Context() stdcontext.Context
+	})
+	if !ok {
+		return nil
+	}
+	return req.Context()
+}
+
+// Deadline returns the deadline of the request from the client, ok is false
+// when no deadline is set.
+func (ctx *Context) Deadline() (deadline time.Time, ok bool) {
+	if c := ctx.stdContext(); c != nil {
+		return c.Deadline()
+	}
+	return
+}
+
+// Done returns a channel that is closed when the request from the client is
+// canceled, e.g. the client disconnected, or its deadline exceeded. Done may
+// return nil if the request can never be canceled, and receiving from a nil
+// channel blocks forever, so filters can always select on it safely.
+func (ctx *Context) Done() <-chan struct{} {
+	if c := ctx.stdContext(); c != nil {
+		return c.Done()
+	}
+	return nil
+}
+
 // Data returns all data that stored in the context.
 func (ctx *Context) Data() map[string]interface{} {
 	return ctx.data
@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2017, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package context
+
+import (
+	stdcontext "context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/megaease/easegress/v2/pkg/protocols/httpprot"
+	"github.com/megaease/easegress/v2/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadlineAndDone(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := New(tracing.NoopSpan)
+	_, ok := ctx.Deadline()
+	assert.False(ok)
+	assert.Nil(ctx.Done())
+
+	deadline := time.Now().Add(time.Hour)
+	stdctx, cancel := stdcontext.WithDeadline(stdcontext.Background(), deadline)
+	stdr, _ := http.NewRequestWithContext(stdctx, http.MethodGet, "http://example.com", nil)
+	req, _ := httpprot.NewRequest(stdr)
+	ctx.SetRequest(DefaultNamespace, req)
+
+	d, ok := ctx.Deadline()
+	assert.True(ok)
+	assert.Equal(deadline, d)
+
+	select {
+	case <-ctx.Done():
+		t.Error("done channel should not be closed")
+	default:
+	}
+
+	cancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("done channel should be closed")
+	}
+}
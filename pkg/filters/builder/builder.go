@@ -75,7 +75,10 @@ func (b *Builder) Status() interface{} {
 func (b *Builder) Close() {
 }
 
-func prepareBuilderData(ctx *context.Context) (map[string]interface{}, error) {
+// PrepareBuilderData prepares the data of ctx for templates, see "Template
+// Of Builder Filters" in the reference of filters for its structure. Other
+// filters supporting templates should use it to keep the data consistent.
+func PrepareBuilderData(ctx *context.Context) (map[string]interface{}, error) {
 	requests := make(map[string]interface{})
 	responses := make(map[string]interface{})
 
@@ -105,9 +105,9 @@ func (db *DataBuilder) reload() {
 
 // Handle builds request.
 func (db *DataBuilder) Handle(ctx *context.Context) (result string) {
-	data, err := prepareBuilderData(ctx)
+	data, err := PrepareBuilderData(ctx)
 	if err != nil {
-		logger.Warnf("prepareBuilderData failed: %v", err)
+		logger.Warnf("PrepareBuilderData failed: %v", err)
 		return resultBuildErr
 	}
 
@@ -242,15 +242,7 @@ func (ra *RequestAdaptor) reload() {
 }
 
 func adaptHeader(h http.Header, as *httpheader.AdaptSpec) {
-	for _, key := range as.Del {
-		h.Del(key)
-	}
-	for key, value := range as.Set {
-		h.Set(key, value)
-	}
-	for key, value := range as.Add {
-		h.Add(key, value)
-	}
+	httpheader.New(h).Adapt(as)
 }
 
 // Handle adapts request.
@@ -260,9 +252,9 @@ func (ra *RequestAdaptor) Handle(ctx *context.Context) string {
 
 	templateSpec := &RequestAdaptorTemplate{}
 	if ra.spec.Template != "" {
-		data, err := prepareBuilderData(ctx)
+		data, err := PrepareBuilderData(ctx)
 		if err != nil {
-			logger.Warnf("prepareBuilderData failed: %v", err)
+			logger.Warnf("PrepareBuilderData failed: %v", err)
 			return resultBuildErr
 		}
 		if err = ra.Builder.build(data, templateSpec); err != nil {
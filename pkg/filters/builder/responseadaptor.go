@@ -134,9 +134,9 @@ func (ra *ResponseAdaptor) Handle(ctx *context.Context) string {
 
 	templateSpec := &ResponseAdaptorTemplate{}
 	if ra.spec.Template != "" {
-		data, err := prepareBuilderData(ctx)
+		data, err := PrepareBuilderData(ctx)
 		if err != nil {
-			logger.Warnf("prepareBuilderData failed: %v", err)
+			logger.Warnf("PrepareBuilderData failed: %v", err)
 			return resultBuildErr
 		}
 
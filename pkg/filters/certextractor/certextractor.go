@@ -19,23 +19,41 @@
 package certextractor
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
 
 	"github.com/megaease/easegress/v2/pkg/context"
 	"github.com/megaease/easegress/v2/pkg/filters"
 	"github.com/megaease/easegress/v2/pkg/protocols/httpprot"
+	"github.com/megaease/easegress/v2/pkg/util/stringtool"
 )
 
 const (
 	// Kind is the kind of CertExtractor.
 	Kind = "CertExtractor"
+
+	resultForbidden = "forbidden"
 )
 
 var kind = &filters.Kind{
 	Name:        Kind,
 	Description: "CertExtractor extracts given field from TLS certificates and sets it to request headers.",
-	Results:     []string{},
+	Results:     []string{resultForbidden},
+	// authorization filters may depend on the extracted identities.
+	Before: []string{"Validator", "OIDCAdaptor", "OPAFilter"},
 	DefaultSpec: func() filters.Spec {
 		return &Spec{}
 	},
@@ -51,8 +69,33 @@ func init() {
 type (
 	// CertExtractor extracts given field from TLS certificates and sets it to request headers.
 	CertExtractor struct {
-		spec      *Spec
-		headerKey string
+		spec *Spec
+		// plan is the extractions executed on every request.
+		plan []*ExtractionSpec
+
+		issuerHeaderKey string
+		depthHeaderKey  string
+
+		cache *certCache
+
+		noTLS      atomic.Uint64
+		noCert     atomic.Uint64
+		emptyField atomic.Uint64
+		extracted  atomic.Uint64
+	}
+
+	// Status is the status of CertExtractor, it counts the requests by the
+	// results of extraction.
+	Status struct {
+		// NoTLS is the number of requests not over TLS.
+		NoTLS uint64 `json:"noTLS"`
+		// NoCert is the number of requests without client certificates.
+		NoCert uint64 `json:"noCert"`
+		// EmptyField is the number of requests whose certificate has none
+		// of the fields to extract.
+		EmptyField uint64 `json:"emptyField"`
+		// Extracted is the number of requests with extracted values.
+		Extracted uint64 `json:"extracted"`
 	}
 
 	// Spec describes the CertExtractor.
@@ -60,15 +103,137 @@ type (
 		filters.BaseSpec `json:",inline"`
 
 		CertIndex int16  `json:"certIndex" jsonschema:"required"`
-		Target    string `json:"target" jsonschema:"required,enum=subject,enum=issuer"`
-		// Different field options listed here https://pkg.go.dev/crypto/x509/pkix#Name
-		Field     string `json:"field" jsonschema:"required,enum=Country,enum=Organization,enum=OrganizationalUnit,enum=Locality,enum=Province,enum=StreetAddress,enum=PostalCode,enum=SerialNumber,enum=CommonName"`
-		HeaderKey string `json:"headerKey" jsonschema:"required"`
+		Target    string `json:"target,omitempty" jsonschema:"enum=,enum=subject,enum=issuer"`
+		// Different field options listed here https://pkg.go.dev/crypto/x509/pkix#Name,
+		// Raw for the whole certificate, and KeyUsage and ExtKeyUsage for
+		// the intended usages of the certificate.
+		Field     string `json:"field,omitempty" jsonschema:"enum=,enum=Country,enum=Organization,enum=OrganizationalUnit,enum=Locality,enum=Province,enum=StreetAddress,enum=PostalCode,enum=SerialNumber,enum=CommonName,enum=Raw,enum=KeyUsage,enum=ExtKeyUsage"`
+		HeaderKey string `json:"headerKey,omitempty"`
+		// PEM outputs the Raw field in URL encoded PEM format instead of
+		// base64 encoded DER.
+		PEM bool `json:"pem,omitempty"`
+		// DefaultValue is set to the header if no value is extracted,
+		// e.g. the field is empty or there's no client certificate.
+		DefaultValue string `json:"defaultValue,omitempty"`
+		// Select controls which values are set to the header when the field
+		// has multiple values, default is all.
+		Select string `json:"select,omitempty" jsonschema:"enum=,enum=all,enum=first,enum=last"`
+		// TrimSpace and Transform normalize the values before they are set
+		// to the header, see ExtractionSpec for details.
+		TrimSpace bool   `json:"trimSpace,omitempty"`
+		Transform string `json:"transform,omitempty" jsonschema:"enum=,enum=lowercase,enum=uppercase,enum=hash"`
+		// ToLower and ToUpper are kept for compatibility, they are the same
+		// as Transform lowercase and uppercase.
+		ToLower bool `json:"toLower,omitempty"`
+		ToUpper bool `json:"toUpper,omitempty"`
+
+		// Extractions are executed on the same certificate after the one
+		// described by Target and Field, so that a single filter can set
+		// multiple headers.
+		Extractions []*ExtractionSpec `json:"extractions,omitempty"`
+
+		VerifiedChain *VerifiedChainSpec `json:"verifiedChain,omitempty"`
+
+		// Allow and Deny are checked against the values of the first
+		// extraction, the request is forbidden if any value matches Deny,
+		// or Allow is not empty and no value matches it.
+		Allow []*stringtool.StringMatcher `json:"allow,omitempty"`
+		Deny  []*stringtool.StringMatcher `json:"deny,omitempty"`
+
+		// StripIncoming removes the headers set by this filter from the
+		// incoming request before extraction, so that clients can't spoof
+		// them.
+		StripIncoming bool `json:"stripIncoming,omitempty"`
+
+		// VerifiedHeaderKey is the header key of whether the client
+		// certificate is verified, the value is true or false.
+		VerifiedHeaderKey string `json:"verifiedHeaderKey,omitempty"`
 	}
+
+	// ExtractionSpec describes a field to extract and the header to set.
+	ExtractionSpec struct {
+		Target       string `json:"target" jsonschema:"required,enum=subject,enum=issuer"`
+		Field        string `json:"field" jsonschema:"required,enum=Country,enum=Organization,enum=OrganizationalUnit,enum=Locality,enum=Province,enum=StreetAddress,enum=PostalCode,enum=SerialNumber,enum=CommonName,enum=Raw,enum=KeyUsage,enum=ExtKeyUsage"`
+		HeaderKey    string `json:"headerKey,omitempty"`
+		PEM          bool   `json:"pem,omitempty"`
+		DefaultValue string `json:"defaultValue,omitempty"`
+		Select       string `json:"select,omitempty" jsonschema:"enum=,enum=all,enum=first,enum=last"`
+		TrimSpace    bool   `json:"trimSpace,omitempty"`
+		// Transform transforms the values after trimming, hash replaces a
+		// value with its hex encoded SHA-256 digest.
+		Transform string `json:"transform,omitempty" jsonschema:"enum=,enum=lowercase,enum=uppercase,enum=hash"`
+	}
+
+	// VerifiedChainSpec describes how to extract information from the first
+	// verified chain of the client certificate.
+	VerifiedChainSpec struct {
+		// IssuerDepth is the index of the issuer in the chain, 1 is the
+		// issuer of the client certificate, negative indexes count from
+		// the end of the chain, that's, -1 is the root CA. 0 means do not
+		// extract the CommonName of the issuer, nothing is extracted either
+		// if the depth is out of the chain.
+		IssuerDepth     int16  `json:"issuerDepth,omitempty"`
+		IssuerHeaderKey string `json:"issuerHeaderKey,omitempty"`
+		// DepthHeaderKey is the header key of the number of intermediate
+		// certificates in the chain.
+		DepthHeaderKey string `json:"depthHeaderKey,omitempty"`
+	}
+)
+
+const (
+	defaultIssuerHeaderKey = "tls-chain-issuer-CommonName"
+	defaultDepthHeaderKey  = "tls-issuer-depth"
 )
 
-// Validate is dummy as jsonschema has already validated Spec.
-func (spec *Spec) Validate() error { return nil }
+// Validate validates the Spec.
+func (spec *Spec) Validate() error {
+	if spec.ToLower && spec.ToUpper {
+		return fmt.Errorf("toLower and toUpper are mutually exclusive")
+	}
+	if spec.Transform != "" && (spec.ToLower || spec.ToUpper) {
+		return fmt.Errorf("transform and toLower/toUpper are mutually exclusive")
+	}
+	if (spec.Target == "") != (spec.Field == "") {
+		return fmt.Errorf("target and field must be specified together")
+	}
+	if spec.Target == "" && len(spec.Extractions) == 0 && spec.VerifiedHeaderKey == "" {
+		return fmt.Errorf("neither target and field, extractions nor verifiedHeaderKey is specified")
+	}
+	if (len(spec.Allow) > 0 || len(spec.Deny) > 0) && spec.Target == "" && len(spec.Extractions) == 0 {
+		return fmt.Errorf("allow and deny require a field to extract")
+	}
+
+	if _, err := expandHeaderKey(spec.HeaderKey, spec.Target, spec.Field); err != nil {
+		return err
+	}
+	for _, e := range spec.Extractions {
+		if _, err := expandHeaderKey(e.HeaderKey, e.Target, e.Field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandHeaderKey expands the header key if it is a template, e.g.
+// x-client-{{.Field}}, which can refer to the Target and Field of the
+// extraction.
+func expandHeaderKey(key, target, field string) (string, error) {
+	if !strings.Contains(key, "{{") {
+		return key, nil
+	}
+
+	t, err := template.New("").Parse(key)
+	if err != nil {
+		return "", fmt.Errorf("invalid template of header key %s: %v", key, err)
+	}
+
+	var buf strings.Builder
+	data := struct{ Target, Field string }{target, field}
+	if err = t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid template of header key %s: %v", key, err)
+	}
+	return buf.String(), nil
+}
 
 // Name returns the name of the CertExtractor filter instance.
 func (ce *CertExtractor) Name() string {
@@ -87,9 +252,54 @@ func (ce *CertExtractor) Spec() filters.Spec {
 
 // Init initializes CertExtractor.
 func (ce *CertExtractor) Init() {
-	ce.headerKey = fmt.Sprintf("tls-%s-%s", ce.spec.Target, ce.spec.Field)
-	if ce.spec.HeaderKey != "" {
-		ce.headerKey = ce.spec.HeaderKey
+	ce.cache = newCertCache(defaultCertCacheSize)
+	for _, m := range ce.spec.Allow {
+		m.Init()
+	}
+	for _, m := range ce.spec.Deny {
+		m.Init()
+	}
+	ce.plan = nil
+	if ce.spec.Target != "" {
+		e := &ExtractionSpec{
+			Target:       ce.spec.Target,
+			Field:        ce.spec.Field,
+			HeaderKey:    ce.spec.HeaderKey,
+			PEM:          ce.spec.PEM,
+			DefaultValue: ce.spec.DefaultValue,
+			Select:       ce.spec.Select,
+			TrimSpace:    ce.spec.TrimSpace,
+			Transform:    ce.spec.Transform,
+		}
+		if ce.spec.ToLower {
+			e.Transform = "lowercase"
+		} else if ce.spec.ToUpper {
+			e.Transform = "uppercase"
+		}
+		ce.plan = append(ce.plan, e)
+	}
+	for _, e := range ce.spec.Extractions {
+		copied := *e
+		ce.plan = append(ce.plan, &copied)
+	}
+	for _, e := range ce.plan {
+		if e.HeaderKey == "" {
+			e.HeaderKey = fmt.Sprintf("tls-%s-%s", e.Target, e.Field)
+		}
+		// the plan is static, so expand the template once here, it has
+		// been validated in Spec.Validate.
+		e.HeaderKey, _ = expandHeaderKey(e.HeaderKey, e.Target, e.Field)
+	}
+
+	if vc := ce.spec.VerifiedChain; vc != nil {
+		ce.issuerHeaderKey = defaultIssuerHeaderKey
+		if vc.IssuerHeaderKey != "" {
+			ce.issuerHeaderKey = vc.IssuerHeaderKey
+		}
+		ce.depthHeaderKey = defaultDepthHeaderKey
+		if vc.DepthHeaderKey != "" {
+			ce.depthHeaderKey = vc.DepthHeaderKey
+		}
 	}
 }
 
@@ -104,14 +314,33 @@ func (ce *CertExtractor) Close() {}
 // Handle retrieves header values and sets request headers.
 func (ce *CertExtractor) Handle(ctx *context.Context) string {
 	r := ctx.GetInputRequest().(*httpprot.Request)
-	connectionState := r.Std().TLS
+	connectionState := r.ConnectionState()
+
+	if ce.spec.StripIncoming {
+		ce.stripIncoming(r)
+	}
+
+	if ce.spec.VerifiedHeaderKey != "" {
+		// always set the header, so that it can't be spoofed by clients.
+		verified := connectionState != nil && len(connectionState.VerifiedChains) > 0
+		r.Header().Set(ce.spec.VerifiedHeaderKey, strconv.FormatBool(verified))
+	}
+
 	if connectionState == nil {
-		return ""
+		ce.noTLS.Add(1)
+		ce.setDefaultValues(r, nil)
+		return ce.checkAccess(ctx, nil)
+	}
+
+	if ce.spec.VerifiedChain != nil {
+		ce.extractVerifiedChain(r, connectionState)
 	}
 
 	certs := connectionState.PeerCertificates
 	if certs == nil || len(certs) < 1 {
-		return ""
+		ce.noCert.Add(1)
+		ce.setDefaultValues(r, nil)
+		return ce.checkAccess(ctx, nil)
 	}
 
 	n := int16(len(certs))
@@ -120,15 +349,107 @@ func (ce *CertExtractor) Handle(ctx *context.Context) string {
 	index := (n + relativeIndex) % n
 	cert := certs[index]
 
+	values, ok := ce.cache.get(cert)
+	if !ok {
+		values = make([][]string, len(ce.plan))
+		for i, e := range ce.plan {
+			values[i] = extract(cert, e)
+		}
+		ce.cache.put(cert, values)
+	}
+	empty := true
+	for i, e := range ce.plan {
+		for _, v := range values[i] {
+			r.Header().Add(e.HeaderKey, v)
+			empty = false
+		}
+	}
+	ce.setDefaultValues(r, values)
+
+	// the plan is empty if only the verified header is required.
+	if len(ce.plan) > 0 {
+		if empty {
+			ce.emptyField.Add(1)
+		} else {
+			ce.extracted.Add(1)
+		}
+	}
+
+	if len(values) == 0 {
+		return ce.checkAccess(ctx, nil)
+	}
+	return ce.checkAccess(ctx, values[0])
+}
+
+// checkAccess checks values against the allow and deny lists, and responds
+// with 403 if the request is forbidden.
+func (ce *CertExtractor) checkAccess(ctx *context.Context, values []string) string {
+	if len(ce.spec.Allow) == 0 && len(ce.spec.Deny) == 0 {
+		return ""
+	}
+
+	allowed := len(ce.spec.Allow) == 0
+	for _, m := range ce.spec.Allow {
+		if m.MatchAny(values) {
+			allowed = true
+			break
+		}
+	}
+	for _, m := range ce.spec.Deny {
+		if m.MatchAny(values) {
+			allowed = false
+			break
+		}
+	}
+	if allowed {
+		return ""
+	}
+
+	ctx.AddTag("certExtractor: forbidden")
+	resp, _ := ctx.GetOutputResponse().(*httpprot.Response)
+	if resp == nil {
+		resp, _ = httpprot.NewResponse(nil)
+	}
+	resp.SetStatusCode(http.StatusForbidden)
+	ctx.SetOutputResponse(resp)
+	return resultForbidden
+}
+
+// setDefaultValues sets the default values to the headers of the extractions
+// without extracted values, values is nil if nothing is extracted.
+func (ce *CertExtractor) setDefaultValues(r *httpprot.Request, values [][]string) {
+	for i, e := range ce.plan {
+		if e.DefaultValue == "" {
+			continue
+		}
+		if values == nil || len(values[i]) == 0 {
+			r.Header().Set(e.HeaderKey, e.DefaultValue)
+		}
+	}
+}
+
+// stripIncoming removes the headers set by this filter from r.
+func (ce *CertExtractor) stripIncoming(r *httpprot.Request) {
+	for _, e := range ce.plan {
+		r.Header().Del(e.HeaderKey)
+	}
+	if ce.spec.VerifiedChain != nil {
+		r.Header().Del(ce.issuerHeaderKey)
+		r.Header().Del(ce.depthHeaderKey)
+	}
+}
+
+// extract extracts the values of the field described by e from cert.
+func extract(cert *x509.Certificate, e *ExtractionSpec) []string {
 	var target pkix.Name
-	if ce.spec.Target == "subject" {
+	if e.Target == "subject" {
 		target = cert.Subject
 	} else {
 		target = cert.Issuer
 	}
 
 	var result []string
-	switch ce.spec.Field {
+	switch e.Field {
 	case "Country":
 		result = target.Country
 	case "Organization":
@@ -147,14 +468,224 @@ func (ce *CertExtractor) Handle(ctx *context.Context) string {
 		result = append(result, target.SerialNumber)
 	case "CommonName":
 		result = append(result, target.CommonName)
+	case "Raw":
+		result = append(result, encodeRaw(cert, e.PEM))
+	case "KeyUsage":
+		result = keyUsages(cert.KeyUsage)
+	case "ExtKeyUsage":
+		result = extKeyUsages(cert)
 	}
+
+	// result may share the underlying array with the certificate, so
+	// normalize into a new slice.
+	values := make([]string, 0, len(result))
 	for _, res := range result {
-		if res != "" {
-			r.Header().Add(ce.headerKey, res)
+		values = append(values, normalize(res, e))
+	}
+	return selectValues(values, e.Select)
+}
+
+var keyUsageNames = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageDigitalSignature, "digitalSignature"},
+	{x509.KeyUsageContentCommitment, "contentCommitment"},
+	{x509.KeyUsageKeyEncipherment, "keyEncipherment"},
+	{x509.KeyUsageDataEncipherment, "dataEncipherment"},
+	{x509.KeyUsageKeyAgreement, "keyAgreement"},
+	{x509.KeyUsageCertSign, "keyCertSign"},
+	{x509.KeyUsageCRLSign, "cRLSign"},
+	{x509.KeyUsageEncipherOnly, "encipherOnly"},
+	{x509.KeyUsageDecipherOnly, "decipherOnly"},
+}
+
+var extKeyUsageNames = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:                            "any",
+	x509.ExtKeyUsageServerAuth:                     "serverAuth",
+	x509.ExtKeyUsageClientAuth:                     "clientAuth",
+	x509.ExtKeyUsageCodeSigning:                    "codeSigning",
+	x509.ExtKeyUsageEmailProtection:                "emailProtection",
+	x509.ExtKeyUsageIPSECEndSystem:                 "ipsecEndSystem",
+	x509.ExtKeyUsageIPSECTunnel:                    "ipsecTunnel",
+	x509.ExtKeyUsageIPSECUser:                      "ipsecUser",
+	x509.ExtKeyUsageTimeStamping:                   "timeStamping",
+	x509.ExtKeyUsageOCSPSigning:                    "OCSPSigning",
+	x509.ExtKeyUsageMicrosoftServerGatedCrypto:     "msServerGatedCrypto",
+	x509.ExtKeyUsageNetscapeServerGatedCrypto:      "nsServerGatedCrypto",
+	x509.ExtKeyUsageMicrosoftCommercialCodeSigning: "msCommercialCodeSigning",
+	x509.ExtKeyUsageMicrosoftKernelCodeSigning:     "msKernelCodeSigning",
+}
+
+// keyUsages returns the names of the key usages in usage, in the order of
+// their bits.
+func keyUsages(usage x509.KeyUsage) []string {
+	var names []string
+	for _, kn := range keyUsageNames {
+		if usage&kn.usage != 0 {
+			names = append(names, kn.name)
+		}
+	}
+	return names
+}
+
+// extKeyUsages returns the names of the extended key usages of cert, usages
+// unknown to Go are represented by their OIDs.
+func extKeyUsages(cert *x509.Certificate) []string {
+	names := make([]string, 0, len(cert.ExtKeyUsage)+len(cert.UnknownExtKeyUsage))
+	for _, usage := range cert.ExtKeyUsage {
+		if name, ok := extKeyUsageNames[usage]; ok {
+			names = append(names, name)
+		}
+	}
+	for _, oid := range cert.UnknownExtKeyUsage {
+		names = append(names, oid.String())
+	}
+	return names
+}
+
+// encodeRaw encodes the whole certificate in base64 encoded DER, or in URL
+// encoded PEM if pemFormat is true, as header values can't contain newlines.
+func encodeRaw(cert *x509.Certificate, pemFormat bool) string {
+	if len(cert.Raw) == 0 {
+		return ""
+	}
+	if !pemFormat {
+		return base64.StdEncoding.EncodeToString(cert.Raw)
+	}
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}
+	return url.QueryEscape(string(pem.EncodeToMemory(block)))
+}
+
+// normalize trims and transforms value according to e.
+func normalize(value string, e *ExtractionSpec) string {
+	if e.TrimSpace {
+		value = strings.TrimSpace(value)
+	}
+	switch e.Transform {
+	case "lowercase":
+		value = strings.ToLower(value)
+	case "uppercase":
+		value = strings.ToUpper(value)
+	case "hash":
+		// an empty value is removed by selectValues, so don't hash it.
+		if value != "" {
+			sum := sha256.Sum256([]byte(value))
+			value = hex.EncodeToString(sum[:])
 		}
 	}
-	return ""
+	return value
+}
+
+// selectValues removes empty values from values, and then selects values
+// according to mode.
+func selectValues(values []string, mode string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+
+	if len(result) == 0 {
+		return result
+	}
+
+	switch mode {
+	case "first":
+		return result[:1]
+	case "last":
+		return result[len(result)-1:]
+	default:
+		return result
+	}
+}
+
+// extractVerifiedChain sets the number of intermediate certificates and the
+// CommonName of the issuer at the configured depth to request headers. It
+// does nothing if the connection is not verified.
+func (ce *CertExtractor) extractVerifiedChain(r *httpprot.Request, cs *tls.ConnectionState) {
+	if len(cs.VerifiedChains) == 0 || len(cs.VerifiedChains[0]) == 0 {
+		return
+	}
+
+	depth := ce.spec.VerifiedChain.IssuerDepth
+	chain := cs.VerifiedChains[0]
+	n := int16(len(chain))
+
+	// the chain begins with the client certificate and ends with the root.
+	intermediates := n - 2
+	if intermediates < 0 {
+		intermediates = 0
+	}
+	r.Header().Set(ce.depthHeaderKey, strconv.Itoa(int(intermediates)))
+
+	// the client certificate itself is not an issuer, so depths out of
+	// the chain are ignored instead of wrapping around to it.
+	if depth == 0 || depth >= n || depth <= -n {
+		return
+	}
+	index := depth
+	if index < 0 {
+		index += n
+	}
+	if cn := chain[index].Subject.CommonName; cn != "" {
+		r.Header().Set(ce.issuerHeaderKey, cn)
+	}
+}
+
+// certCache caches the extracted values of certificates, one slice for each
+// extraction of the plan. The key is the
+// pointer of the certificate, which is shared by all requests of a TLS
+// connection, so the values are computed once per connection. A new
+// handshake, e.g. a renegotiation, produces new certificates and never
+// hits the stale values.
+type certCache struct {
+	lock   sync.RWMutex
+	size   int
+	values map[*x509.Certificate][][]string
+}
+
+const defaultCertCacheSize = 4096
+
+func newCertCache(size int) *certCache {
+	return &certCache{
+		size:   size,
+		values: make(map[*x509.Certificate][][]string, size),
+	}
+}
+
+// get returns the cached values of cert, a nil cache never hits.
+func (c *certCache) get(cert *x509.Certificate) ([][]string, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	v, ok := c.values[cert]
+	return v, ok
+}
+
+// put adds the values of cert to the cache, the cache is reset when it is
+// full, so the certificates of closed connections are released eventually.
+func (c *certCache) put(cert *x509.Certificate, values [][]string) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.values) >= c.size {
+		c.values = make(map[*x509.Certificate][][]string, c.size)
+	}
+	c.values[cert] = values
 }
 
 // Status returns status.
-func (ce *CertExtractor) Status() interface{} { return nil }
+func (ce *CertExtractor) Status() interface{} {
+	return &Status{
+		NoTLS:      ce.noTLS.Load(),
+		NoCert:     ce.noCert.Load(),
+		EmptyField: ce.emptyField.Load(),
+		Extracted:  ce.extracted.Load(),
+	}
+}
@@ -17,13 +17,20 @@
 package certextractor
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/megaease/easegress/v2/pkg/context"
@@ -81,14 +88,14 @@ field: "CommonName"
 `
 	ce, err := createCertExtractor(yaml, nil, nil)
 	assert.Nil(err)
-	assert.Nil(ce.Status())
+	assert.Equal(&Status{}, ce.Status())
 	ce, err = createCertExtractor(yaml, ce, nil)
 	assert.Nil(err)
-	assert.Equal(ce.headerKey, "key")
+	assert.Equal("key", ce.plan[0].HeaderKey)
 
 	ce, err = createCertExtractor(yamlNoKey, ce, nil)
 	assert.Nil(err)
-	assert.Equal(ce.headerKey, "tls-subject-CommonName")
+	assert.Equal("tls-subject-CommonName", ce.plan[0].HeaderKey)
 }
 
 func prepareCtxAndHeader(t *testing.T, connState *tls.ConnectionState) (*context.Context, http.Header) {
@@ -206,3 +213,612 @@ field: "Province"
 		})
 	})
 }
+
+func TestConnectionState(t *testing.T) {
+	assert := assert.New(t)
+
+	ce, err := createCertExtractor(yaml, nil, nil)
+	assert.Nil(err)
+
+	// the request has no TLS, the connection state is injected.
+	handle := func(connState *tls.ConnectionState) http.Header {
+		ctx, header := prepareCtxAndHeader(t, nil)
+		ctx.GetInputRequest().(*httpprot.Request).SetConnectionState(connState)
+		ce.Handle(ctx)
+		return header
+	}
+
+	header := handle(nil)
+	assert.Empty(header.Values("key"))
+
+	header = handle(&tls.ConnectionState{})
+	assert.Empty(header.Values("key"))
+
+	header = handle(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+		{Subject: pkix.Name{CommonName: "first"}},
+		{Subject: pkix.Name{CommonName: "last"}},
+	}})
+	assert.Equal([]string{"last"}, header.Values("key"))
+}
+
+func TestVerifiedChain(t *testing.T) {
+	assert := assert.New(t)
+
+	leaf := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}}
+	intermediate := &x509.Certificate{Subject: pkix.Name{CommonName: "intermediate"}}
+	root := &x509.Certificate{Subject: pkix.Name{CommonName: "root"}}
+
+	yamlConfig := yaml + `
+verifiedChain:
+  issuerDepth: 1
+`
+	t.Run("unverified", func(t *testing.T) {
+		connState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}
+		ctx, header := prepareCtxAndHeader(t, connState)
+		ce, _ := createCertExtractor(yamlConfig, nil, nil)
+		assert.Equal("", ce.Handle(ctx))
+		assert.Equal("", header.Get("tls-issuer-depth"))
+		assert.Equal("", header.Get("tls-chain-issuer-CommonName"))
+		assert.Equal("client", header.Get("key"))
+	})
+
+	t.Run("issued by intermediate", func(t *testing.T) {
+		connState := &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{leaf},
+			VerifiedChains:   [][]*x509.Certificate{{leaf, intermediate, root}},
+		}
+		ctx, header := prepareCtxAndHeader(t, connState)
+		ce, _ := createCertExtractor(yamlConfig, nil, nil)
+		assert.Equal("", ce.Handle(ctx))
+		assert.Equal("1", header.Get("tls-issuer-depth"))
+		assert.Equal("intermediate", header.Get("tls-chain-issuer-CommonName"))
+
+		ctx, header = prepareCtxAndHeader(t, connState)
+		ce, _ = createCertExtractor(yaml+`
+verifiedChain:
+  issuerDepth: -1
+  issuerHeaderKey: x-issuer
+  depthHeaderKey: x-depth
+`, nil, nil)
+		assert.Equal("", ce.Handle(ctx))
+		assert.Equal("1", header.Get("x-depth"))
+		assert.Equal("root", header.Get("x-issuer"))
+	})
+
+	t.Run("issued by root", func(t *testing.T) {
+		connState := &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{leaf},
+			VerifiedChains:   [][]*x509.Certificate{{leaf, root}},
+		}
+		ctx, header := prepareCtxAndHeader(t, connState)
+		ce, _ := createCertExtractor(yamlConfig, nil, nil)
+		assert.Equal("", ce.Handle(ctx))
+		assert.Equal("0", header.Get("tls-issuer-depth"))
+		assert.Equal("root", header.Get("tls-chain-issuer-CommonName"))
+
+		// depths out of the chain never report the client certificate.
+		for _, depth := range []string{"2", "-2", "5", "-5"} {
+			ctx, header = prepareCtxAndHeader(t, connState)
+			ce, _ = createCertExtractor(yaml+`
+verifiedChain:
+  issuerDepth: `+depth+`
+`, nil, nil)
+			assert.Equal("", ce.Handle(ctx), depth)
+			assert.Equal("0", header.Get("tls-issuer-depth"), depth)
+			assert.Empty(header.Values("tls-chain-issuer-CommonName"), depth)
+		}
+	})
+}
+
+func TestSelect(t *testing.T) {
+	assert := assert.New(t)
+
+	peerCertificates := []*x509.Certificate{{
+		Subject: pkix.Name{Organization: []string{"", "org1", "org2", "org3"}},
+	}}
+	connState := &tls.ConnectionState{PeerCertificates: peerCertificates}
+	yamlConfig := strings.ReplaceAll(yaml, `field: "CommonName"`, `field: "Organization"`)
+
+	for mode, expected := range map[string][]string{
+		"":      {"org1", "org2", "org3"},
+		"all":   {"org1", "org2", "org3"},
+		"first": {"org1"},
+		"last":  {"org3"},
+	} {
+		ctx, header := prepareCtxAndHeader(t, connState)
+		ce, err := createCertExtractor(yamlConfig+"select: \""+mode+"\"\n", nil, nil)
+		assert.Nil(err)
+		assert.Equal("", ce.Handle(ctx))
+		assert.Equal(expected, header.Values("key"), mode)
+	}
+
+	assert.Empty(selectValues([]string{""}, "first"))
+	assert.Empty(selectValues(nil, "last"))
+}
+
+func TestNormalize(t *testing.T) {
+	assert := assert.New(t)
+
+	peerCertificates := []*x509.Certificate{{
+		Subject: pkix.Name{Organization: []string{" Org1 ", "  "}},
+	}}
+	connState := &tls.ConnectionState{PeerCertificates: peerCertificates}
+	yamlConfig := strings.ReplaceAll(yaml, `field: "CommonName"`, `field: "Organization"`)
+
+	ctx, header := prepareCtxAndHeader(t, connState)
+	ce, err := createCertExtractor(yamlConfig, nil, nil)
+	assert.Nil(err)
+	ce.Handle(ctx)
+	assert.Equal([]string{" Org1 ", "  "}, header.Values("key"))
+
+	ctx, header = prepareCtxAndHeader(t, connState)
+	ce, err = createCertExtractor(yamlConfig+"trimSpace: true\ntoLower: true\n", nil, nil)
+	assert.Nil(err)
+	ce.Handle(ctx)
+	assert.Equal([]string{"org1"}, header.Values("key"))
+
+	ctx, header = prepareCtxAndHeader(t, connState)
+	ce, err = createCertExtractor(yamlConfig+"trimSpace: true\ntoUpper: true\n", nil, nil)
+	assert.Nil(err)
+	ce.Handle(ctx)
+	assert.Equal([]string{"ORG1"}, header.Values("key"))
+
+	// the certificate is not modified.
+	assert.Equal(" Org1 ", peerCertificates[0].Subject.Organization[0])
+
+	ctx, header = prepareCtxAndHeader(t, connState)
+	ce, err = createCertExtractor(yamlConfig+"trimSpace: true\ntransform: uppercase\n", nil, nil)
+	assert.Nil(err)
+	ce.Handle(ctx)
+	assert.Equal([]string{"ORG1"}, header.Values("key"))
+
+	ctx, header = prepareCtxAndHeader(t, connState)
+	ce, err = createCertExtractor(yamlConfig+"trimSpace: true\ntransform: hash\n", nil, nil)
+	assert.Nil(err)
+	ce.Handle(ctx)
+	sum := sha256.Sum256([]byte("Org1"))
+	assert.Equal([]string{hex.EncodeToString(sum[:])}, header.Values("key"))
+
+	_, err = createCertExtractor(yamlConfig+"toLower: true\ntoUpper: true\n", nil, nil)
+	assert.NotNil(err)
+	_, err = createCertExtractor(yamlConfig+"toLower: true\ntransform: hash\n", nil, nil)
+	assert.NotNil(err)
+}
+
+func TestCertCache(t *testing.T) {
+	assert := assert.New(t)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}}
+	connState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	ce, _ := createCertExtractor(yaml, nil, nil)
+
+	ctx, header := prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	assert.Equal("client", header.Get("key"))
+	values, ok := ce.cache.get(cert)
+	assert.True(ok)
+	assert.Equal([][]string{{"client"}}, values)
+
+	// a new handshake comes with new certificates.
+	renegotiated := &x509.Certificate{Subject: pkix.Name{CommonName: "renegotiated"}}
+	connState = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{renegotiated}}
+	ctx, header = prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	assert.Equal("renegotiated", header.Get("key"))
+
+	cache := newCertCache(1)
+	cache.put(cert, [][]string{{"a"}})
+	cache.put(renegotiated, [][]string{{"b"}})
+	_, ok = cache.get(cert)
+	assert.False(ok)
+	values, ok = cache.get(renegotiated)
+	assert.True(ok)
+	assert.Equal([][]string{{"b"}}, values)
+}
+
+func BenchmarkHandle(b *testing.B) {
+	cert := &x509.Certificate{Subject: pkix.Name{
+		Organization: []string{" Org1 ", " Org2 ", " Org3 "},
+	}}
+	connState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	yamlConfig := strings.ReplaceAll(yaml, `field: "CommonName"`, `field: "Organization"`)
+	yamlConfig += "trimSpace: true\ntoLower: true\n"
+
+	stdr := &http.Request{Header: http.Header{}, TLS: connState}
+	req, _ := httpprot.NewRequest(stdr)
+	ctx := context.New(tracing.NoopSpan)
+	ctx.SetRequest(context.DefaultNamespace, req)
+
+	run := func(b *testing.B, ce *CertExtractor) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			stdr.Header = http.Header{}
+			ce.Handle(ctx)
+		}
+	}
+
+	b.Run("cached", func(b *testing.B) {
+		ce, _ := createCertExtractor(yamlConfig, nil, nil)
+		run(b, ce)
+	})
+
+	b.Run("uncached", func(b *testing.B) {
+		ce, _ := createCertExtractor(yamlConfig, nil, nil)
+		ce.cache = nil
+		run(b, ce)
+	})
+}
+
+func TestExtractions(t *testing.T) {
+	assert := assert.New(t)
+
+	cert := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:   " Client ",
+			Organization: []string{"org1", "org2"},
+		},
+		Issuer: pkix.Name{CommonName: "ca"},
+	}
+	connState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+
+	yamlConfig := yaml + `
+extractions:
+- target: subject
+  field: CommonName
+  headerKey: cn-hash
+  trimSpace: true
+  transform: hash
+- target: subject
+  field: Organization
+  select: last
+  transform: uppercase
+- target: issuer
+  field: CommonName
+`
+	ce, err := createCertExtractor(yamlConfig, nil, nil)
+	assert.Nil(err)
+	assert.Len(ce.plan, 4)
+
+	ctx, header := prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	assert.Equal(" Client ", header.Get("key"))
+	sum := sha256.Sum256([]byte("Client"))
+	assert.Equal(hex.EncodeToString(sum[:]), header.Get("cn-hash"))
+	assert.Equal([]string{"ORG2"}, header.Values("tls-subject-Organization"))
+	assert.Equal("ca", header.Get("tls-issuer-CommonName"))
+
+	// extractions only
+	yamlConfig = `
+kind: CertExtractor
+name: cn-extractor
+certIndex: 0
+extractions:
+- target: issuer
+  field: CommonName
+  headerKey: issuer
+`
+	ce, err = createCertExtractor(yamlConfig, nil, nil)
+	assert.Nil(err)
+	ctx, header = prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	assert.Equal("ca", header.Get("issuer"))
+
+	// nothing to extract
+	_, err = createCertExtractor("kind: CertExtractor\nname: ce\ncertIndex: 0\n", nil, nil)
+	assert.NotNil(err)
+
+	// target without field
+	_, err = createCertExtractor("kind: CertExtractor\nname: ce\ncertIndex: 0\ntarget: subject\n", nil, nil)
+	assert.NotNil(err)
+}
+
+func TestRaw(t *testing.T) {
+	assert := assert.New(t)
+
+	first := &x509.Certificate{Raw: []byte("first")}
+	last := &x509.Certificate{Raw: []byte("last")}
+	connState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{first, last}}
+	yamlConfig := strings.ReplaceAll(yaml, `field: "CommonName"`, `field: "Raw"`)
+
+	ce, err := createCertExtractor(yamlConfig, nil, nil)
+	assert.Nil(err)
+	ctx, header := prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	assert.Equal(base64.StdEncoding.EncodeToString([]byte("last")), header.Get("key"))
+
+	ce, err = createCertExtractor(yamlConfig+"pem: true\n", nil, nil)
+	assert.Nil(err)
+	ctx, header = prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	value, err := url.QueryUnescape(header.Get("key"))
+	assert.Nil(err)
+	block, _ := pem.Decode([]byte(value))
+	assert.Equal("CERTIFICATE", block.Type)
+	assert.Equal([]byte("last"), block.Bytes)
+
+	// no raw data
+	connState = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}}
+	ctx, header = prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	assert.Empty(header.Values("key"))
+}
+
+func TestVerifiedHeaderKey(t *testing.T) {
+	assert := assert.New(t)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}}
+	yamlConfig := yaml + "verifiedHeaderKey: tls-client-verified\n"
+	ce, err := createCertExtractor(yamlConfig, nil, nil)
+	assert.Nil(err)
+
+	ctx, header := prepareCtxAndHeader(t, nil)
+	header.Set("tls-client-verified", "true")
+	ce.Handle(ctx)
+	assert.Equal("false", header.Get("tls-client-verified"))
+
+	connState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	ctx, header = prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	assert.Equal("false", header.Get("tls-client-verified"))
+	assert.Equal("client", header.Get("key"))
+
+	connState.VerifiedChains = [][]*x509.Certificate{{cert}}
+	ctx, header = prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	assert.Equal("true", header.Get("tls-client-verified"))
+
+	// without field extraction
+	yamlConfig = `
+kind: CertExtractor
+name: verified
+certIndex: 0
+verifiedHeaderKey: tls-client-verified
+`
+	ce, err = createCertExtractor(yamlConfig, nil, nil)
+	assert.Nil(err)
+	assert.Empty(ce.plan)
+	ctx, header = prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	assert.Equal("true", header.Get("tls-client-verified"))
+}
+
+func TestStripIncoming(t *testing.T) {
+	assert := assert.New(t)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}}
+	connState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+
+	ce, err := createCertExtractor(yaml, nil, nil)
+	assert.Nil(err)
+	ctx, header := prepareCtxAndHeader(t, connState)
+	header.Set("key", "spoofed")
+	ce.Handle(ctx)
+	assert.Equal([]string{"spoofed", "client"}, header.Values("key"))
+
+	yamlConfig := yaml + `
+stripIncoming: true
+verifiedChain:
+  issuerDepth: 1
+`
+	ce, err = createCertExtractor(yamlConfig, nil, nil)
+	assert.Nil(err)
+	ctx, header = prepareCtxAndHeader(t, connState)
+	header.Set("key", "spoofed")
+	header.Set("tls-chain-issuer-CommonName", "spoofed")
+	header.Set("tls-issuer-depth", "spoofed")
+	ce.Handle(ctx)
+	assert.Equal([]string{"client"}, header.Values("key"))
+	assert.Empty(header.Values("tls-chain-issuer-CommonName"))
+	assert.Empty(header.Values("tls-issuer-depth"))
+
+	// headers are stripped even without TLS.
+	ctx, header = prepareCtxAndHeader(t, nil)
+	header.Set("key", "spoofed")
+	ce.Handle(ctx)
+	assert.Empty(header.Values("key"))
+}
+
+func TestDefaultValue(t *testing.T) {
+	assert := assert.New(t)
+
+	yamlConfig := yaml + `
+defaultValue: anonymous
+stripIncoming: true
+extractions:
+- target: subject
+  field: Organization
+  headerKey: org
+  defaultValue: none
+- target: subject
+  field: Country
+  headerKey: country
+`
+	ce, err := createCertExtractor(yamlConfig, nil, nil)
+	assert.Nil(err)
+
+	// no TLS, spoofed headers are replaced by the default values.
+	ctx, header := prepareCtxAndHeader(t, nil)
+	header.Set("key", "spoofed")
+	header.Set("country", "spoofed")
+	ce.Handle(ctx)
+	assert.Equal([]string{"anonymous"}, header.Values("key"))
+	assert.Equal([]string{"none"}, header.Values("org"))
+	assert.Empty(header.Values("country"))
+
+	// no client certificate.
+	ctx, header = prepareCtxAndHeader(t, &tls.ConnectionState{})
+	ce.Handle(ctx)
+	assert.Equal([]string{"anonymous"}, header.Values("key"))
+	assert.Equal([]string{"none"}, header.Values("org"))
+
+	// empty field.
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}}
+	connState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	ctx, header = prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	assert.Equal([]string{"client"}, header.Values("key"))
+	assert.Equal([]string{"none"}, header.Values("org"))
+	assert.Empty(header.Values("country"))
+}
+
+func TestKeyUsage(t *testing.T) {
+	assert := assert.New(t)
+
+	cert := &x509.Certificate{
+		KeyUsage:           x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:        []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		UnknownExtKeyUsage: []asn1.ObjectIdentifier{{1, 2, 3, 4}},
+	}
+	connState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	yamlConfig := `
+kind: CertExtractor
+name: usage-extractor
+certIndex: 0
+extractions:
+- target: subject
+  field: KeyUsage
+  headerKey: tls-ku
+- target: subject
+  field: ExtKeyUsage
+  headerKey: tls-eku
+`
+	ce, err := createCertExtractor(yamlConfig, nil, nil)
+	assert.Nil(err)
+	ctx, header := prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	assert.Equal([]string{"digitalSignature", "keyEncipherment"}, header.Values("tls-ku"))
+	assert.Equal([]string{"clientAuth", "serverAuth", "1.2.3.4"}, header.Values("tls-eku"))
+
+	// no key usage
+	connState = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}}
+	ctx, header = prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	assert.Empty(header.Values("tls-ku"))
+	assert.Empty(header.Values("tls-eku"))
+}
+
+func TestAllowDeny(t *testing.T) {
+	assert := assert.New(t)
+
+	newConnState := func(cn string) *tls.ConnectionState {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+		return &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	}
+
+	yamlConfig := yaml + `
+allow:
+- prefix: svc-
+deny:
+- exact: svc-banned
+- regex: ^.*-test$
+`
+	ce, err := createCertExtractor(yamlConfig, nil, nil)
+	assert.Nil(err)
+	assert.Contains(ce.Kind().Results, resultForbidden)
+
+	for cn, expected := range map[string]string{
+		"svc-orders": "",
+		"svc-banned": resultForbidden,
+		"svc-a-test": resultForbidden,
+		"user":       resultForbidden,
+		"":           resultForbidden,
+	} {
+		ctx, _ := prepareCtxAndHeader(t, newConnState(cn))
+		assert.Equal(expected, ce.Handle(ctx), cn)
+		if expected != "" {
+			resp := ctx.GetOutputResponse().(*httpprot.Response)
+			assert.Equal(http.StatusForbidden, resp.StatusCode(), cn)
+		}
+	}
+
+	ctx, _ := prepareCtxAndHeader(t, nil)
+	assert.Equal(resultForbidden, ce.Handle(ctx))
+
+	// deny only
+	yamlConfig = yaml + `
+deny:
+- exact: banned
+`
+	ce, err = createCertExtractor(yamlConfig, nil, nil)
+	assert.Nil(err)
+	ctx, _ = prepareCtxAndHeader(t, newConnState("banned"))
+	assert.Equal(resultForbidden, ce.Handle(ctx))
+	ctx, _ = prepareCtxAndHeader(t, newConnState("user"))
+	assert.Equal("", ce.Handle(ctx))
+	ctx, _ = prepareCtxAndHeader(t, nil)
+	assert.Equal("", ce.Handle(ctx))
+
+	// invalid matcher
+	_, err = createCertExtractor(yaml+"allow:\n- empty: false\n", nil, nil)
+	assert.NotNil(err)
+}
+
+func TestStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	ce, err := createCertExtractor(yaml, nil, nil)
+	assert.Nil(err)
+
+	handle := func(connState *tls.ConnectionState, times int) {
+		var wg sync.WaitGroup
+		for i := 0; i < times; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx, _ := prepareCtxAndHeader(t, connState)
+				ce.Handle(ctx)
+			}()
+		}
+		wg.Wait()
+	}
+
+	handle(nil, 1)
+	handle(&tls.ConnectionState{}, 2)
+	handle(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}}, 3)
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}}
+	handle(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}, 10)
+
+	assert.Equal(&Status{
+		NoTLS:      1,
+		NoCert:     2,
+		EmptyField: 3,
+		Extracted:  10,
+	}, ce.Status())
+}
+
+func TestHeaderKeyTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	cert := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "client", Organization: []string{"org"}},
+		Issuer:  pkix.Name{CommonName: "ca"},
+	}
+	connState := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	yamlConfig := `
+kind: CertExtractor
+name: cn-extractor
+certIndex: 0
+target: subject
+field: CommonName
+headerKey: x-client-{{.Field}}
+extractions:
+- target: subject
+  field: Organization
+  headerKey: x-client-{{.Field}}
+- target: issuer
+  field: CommonName
+  headerKey: x-{{.Target}}-{{.Field}}
+`
+	ce, err := createCertExtractor(yamlConfig, nil, nil)
+	assert.Nil(err)
+	ctx, header := prepareCtxAndHeader(t, connState)
+	ce.Handle(ctx)
+	assert.Equal("client", header.Get("x-client-CommonName"))
+	assert.Equal("org", header.Get("x-client-Organization"))
+	assert.Equal("ca", header.Get("x-issuer-CommonName"))
+
+	_, err = createCertExtractor(strings.ReplaceAll(yaml, `headerKey: "key"`, `headerKey: "x-{{.Field"`), nil, nil)
+	assert.NotNil(err)
+	_, err = createCertExtractor(strings.ReplaceAll(yaml, `headerKey: "key"`, `headerKey: "x-{{.Unknown}}"`), nil, nil)
+	assert.NotNil(err)
+}
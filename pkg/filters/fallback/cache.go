@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2017, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fallback
+
+import (
+	"sync"
+
+	"github.com/megaease/easegress/v2/pkg/protocols/httpprot"
+)
+
+// ResponseCache is a cache of the last known good response bodies, it is
+// usually filled by a caching filter and consulted by Fallback.
+type ResponseCache interface {
+	// Get returns the most recent successful body of key.
+	Get(key string) ([]byte, bool)
+}
+
+var caches sync.Map
+
+// RegisterResponseCache registers cache with name, so that Fallback can refer
+// to it by the name. A cache registered with the same name is replaced.
+func RegisterResponseCache(name string, cache ResponseCache) {
+	caches.Store(name, cache)
+}
+
+// UnregisterResponseCache unregisters the cache of name.
+func UnregisterResponseCache(name string) {
+	caches.Delete(name)
+}
+
+func getResponseCache(name string) ResponseCache {
+	v, ok := caches.Load(name)
+	if !ok {
+		return nil
+	}
+	return v.(ResponseCache)
+}
+
+// CacheKey returns the cache key of req, caching filters should use it as the
+// key of the responses so that Fallback can find them. The key contains the
+// host of req, as the URL of a request received by a server has no host, and
+// virtual hosts may be routed to the same pipeline.
+func CacheKey(req *httpprot.Request) string {
+	return req.Method() + " " + req.Host() + req.URL().RequestURI()
+}
@@ -19,12 +19,26 @@
 package fallback
 
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
 
+	sprig "github.com/go-task/slim-sprig"
 	"github.com/megaease/easegress/v2/pkg/context"
 	"github.com/megaease/easegress/v2/pkg/filters"
+	"github.com/megaease/easegress/v2/pkg/filters/builder"
+	"github.com/megaease/easegress/v2/pkg/logger"
 	"github.com/megaease/easegress/v2/pkg/protocols/httpprot"
+	"github.com/megaease/easegress/v2/pkg/util/codectool"
 )
 
 const (
@@ -33,6 +47,8 @@ const (
 
 	resultFallback         = "fallback"
 	resultResponseNotFound = "responseNotFound"
+
+	truncatedHeaderKey = "X-Fallback-Truncated"
 )
 
 var kind = &filters.Kind{
@@ -54,21 +70,179 @@ func init() {
 type (
 	// Fallback is filter Fallback.
 	Fallback struct {
-		spec       *Spec
-		mockBody   []byte
-		bodyLength string
+		spec         *Spec
+		mocks        []*mock
+		totalWeight  int
+		intn         func(n int) int
+		lastResponse atomic.Pointer[MockedResponse]
+		metrics      atomic.Pointer[metricsSinkHolder]
+	}
+
+	// mock is a mocked response prepared from the spec.
+	mock struct {
+		code            int
+		weight          int
+		headers         map[string]string
+		body            []byte
+		bodyLength      string
+		headerTemplates map[string]*template.Template
+		response        *MockedResponse
+
+		// problemDetails is used to rebuild the body if the status code
+		// is preserved, as the status in the body must match it.
+		problemDetails *ProblemDetails
+
+		// negotiated are the mocks for media types, mediaTypes are their
+		// keys in a stable order for wildcard matching.
+		negotiated map[string]*mock
+		mediaTypes []string
+	}
+
+	// MockedResponse records a response written by Fallback, it is mainly
+	// for verifying the fallback behavior in tests.
+	MockedResponse struct {
+		StatusCode int
+		Header     http.Header
+		// BodyLength is -1 if the length of the body is unknown.
+		BodyLength int
 	}
 
 	// Spec describes the Fallback.
 	Spec struct {
 		filters.BaseSpec `json:",inline"`
 
+		MockCode int `json:"mockCode" jsonschema:"required,format=httpcode"`
+		// MockHeaders are the headers of the mocked response, a value
+		// containing "{{" is a template, which is evaluated against the
+		// same data as the templates of the builder filters.
+		MockHeaders map[string]string `json:"mockHeaders,omitempty"`
+		MockBody    string            `json:"mockBody,omitempty"`
+		// ProblemDetails is an alternative of MockBody, the mocked body is
+		// the RFC 7807 problem details in JSON with MockCode as the status.
+		// It can't be used with Variants.
+		ProblemDetails *ProblemDetails `json:"problemDetails,omitempty"`
+
+		// Variants are mocked responses selected randomly by their weights,
+		// MockCode, MockHeaders and MockBody are ignored if Variants is not
+		// empty.
+		Variants []*WeightedMock `json:"variants,omitempty"`
+
+		// Seed seeds the random selection of Variants if it is not zero,
+		// so that the sequence of the selected variants is deterministic,
+		// which is mainly for testing.
+		Seed int64 `json:"seed,omitempty"`
+
+		// CloseConnection sets "Connection: close" to the response, so that
+		// clients reconnect instead of reusing the connection.
+		CloseConnection bool `json:"closeConnection,omitempty"`
+
+		// ResponseCache is the name of a registered ResponseCache, if it is
+		// not empty, the last known good body of the request is served
+		// instead of the mocked body, the mocked body is served on cache miss.
+		ResponseCache string `json:"responseCache,omitempty"`
+
+		// ContentTypes are the mocked bodies and headers for media types,
+		// which are selected by the Accept header of the request. The
+		// mocked body of the default response is used if no media type
+		// matches.
+		ContentTypes map[string]*ContentTypeMock `json:"contentTypes,omitempty"`
+
+		// Echo returns the body of the request as the response body, with
+		// headers X-Echo-Method and X-Echo-Path describing the request, the
+		// status code and headers are still from the mocked response.
+		Echo bool `json:"echo,omitempty"`
+
+		// PreserveStatus keeps the status code of the response, e.g. set
+		// by the upstream, if it is an error (4xx or 5xx), instead of
+		// overwriting it with the mocked one. Other status codes are not
+		// kept, as the response is created with 200 by default.
+		PreserveStatus bool `json:"preserveStatus,omitempty"`
+
+		// MaxBodyBytes limits the size of the dynamic bodies, i.e. the echoed
+		// and the cached bodies, a body exceeding the limit is truncated and
+		// the header X-Fallback-Truncated is set. 0 means no limit.
+		MaxBodyBytes int64 `json:"maxBodyBytes,omitempty" jsonschema:"minimum=0"`
+	}
+
+	// ProblemDetails is the problem details defined by RFC 7807.
+	ProblemDetails struct {
+		Type     string `json:"type,omitempty"`
+		Title    string `json:"title,omitempty"`
+		Detail   string `json:"detail,omitempty"`
+		Instance string `json:"instance,omitempty"`
+	}
+
+	// ContentTypeMock is the mocked body and headers for a media type, the
+	// headers are merged into the headers of the mocked response.
+	ContentTypeMock struct {
+		MockHeaders map[string]string `json:"mockHeaders,omitempty"`
+		MockBody    string            `json:"mockBody,omitempty"`
+	}
+
+	// WeightedMock is a mocked response with a weight.
+	WeightedMock struct {
+		Weight      int               `json:"weight" jsonschema:"required,minimum=1"`
 		MockCode    int               `json:"mockCode" jsonschema:"required,format=httpcode"`
 		MockHeaders map[string]string `json:"mockHeaders,omitempty"`
 		MockBody    string            `json:"mockBody,omitempty"`
 	}
 )
 
+// Validate validates the spec.
+func (spec *Spec) Validate() error {
+	if spec.ProblemDetails != nil && spec.MockBody != "" {
+		return fmt.Errorf("mockBody and problemDetails are mutually exclusive")
+	}
+	if spec.ProblemDetails != nil && len(spec.Variants) > 0 {
+		return fmt.Errorf("variants and problemDetails are mutually exclusive")
+	}
+	if err := validateHeaders(spec.MockHeaders); err != nil {
+		return err
+	}
+	for _, v := range spec.Variants {
+		if err := validateHeaders(v.MockHeaders); err != nil {
+			return err
+		}
+	}
+	mediaTypes := map[string]string{}
+	for mediaType, ct := range spec.ContentTypes {
+		t, _, err := mime.ParseMediaType(mediaType)
+		if err != nil || !strings.Contains(t, "/") {
+			return fmt.Errorf("invalid media type %s", mediaType)
+		}
+		// the mocks are selected by the parsed media types, which must be
+		// unique to select a mock deterministically.
+		if prev, ok := mediaTypes[t]; ok {
+			return fmt.Errorf("media types %s and %s are duplicated", prev, mediaType)
+		}
+		mediaTypes[t] = mediaType
+		if err := validateHeaders(ct.MockHeaders); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateHeaders(headers map[string]string) error {
+	for key, value := range headers {
+		if !isTemplate(value) {
+			continue
+		}
+		if _, err := newTemplate(value); err != nil {
+			return fmt.Errorf("invalid template of mock header %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+func isTemplate(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+func newTemplate(text string) (*template.Template, error) {
+	return template.New("").Funcs(sprig.TxtFuncMap()).Parse(text)
+}
+
 // Name returns the name of the Fallback filter instance.
 func (f *Fallback) Name() string {
 	return f.spec.Name()
@@ -91,12 +265,237 @@ func (f *Fallback) Init() {
 
 // Inherit inherits previous generation of Fallback.
 func (f *Fallback) Inherit(previousGeneration filters.Filter) {
+	if prev, ok := previousGeneration.(*Fallback); ok {
+		if h := prev.metrics.Load(); h != nil {
+			f.metrics.Store(h)
+		}
+	}
 	f.Init()
 }
 
 func (f *Fallback) reload() {
-	f.mockBody = []byte(f.spec.MockBody)
-	f.bodyLength = strconv.Itoa(len(f.mockBody))
+	f.intn = rand.Intn
+	if f.spec.Seed != 0 {
+		// rand.Rand is not safe for concurrent use.
+		var mu sync.Mutex
+		rnd := rand.New(rand.NewSource(f.spec.Seed))
+		f.intn = func(n int) int {
+			mu.Lock()
+			defer mu.Unlock()
+			return rnd.Intn(n)
+		}
+	}
+	f.mocks, f.totalWeight = nil, 0
+
+	if len(f.spec.Variants) == 0 {
+		headers, body := f.spec.MockHeaders, f.spec.MockBody
+		if pd := f.spec.ProblemDetails; pd != nil {
+			headers, body = pd.headers(headers), pd.body(f.spec.MockCode)
+		}
+		m := newMock(f.spec.MockCode, 1, headers, body)
+		m.problemDetails = f.spec.ProblemDetails
+		// the Content-Type of problem details is only for the default body.
+		f.negotiate(m, f.spec.MockHeaders)
+		f.mocks = append(f.mocks, m)
+	} else {
+		for _, v := range f.spec.Variants {
+			m := newMock(v.MockCode, v.Weight, v.MockHeaders, v.MockBody)
+			f.negotiate(m, v.MockHeaders)
+			f.mocks = append(f.mocks, m)
+		}
+	}
+
+	for _, m := range f.mocks {
+		f.totalWeight += m.weight
+		for _, mm := range append([]*mock{m}, m.mocksOfMediaTypes()...) {
+			if f.spec.CloseConnection {
+				mm.response.Header.Set("Connection", "close")
+			}
+			if len(f.spec.ContentTypes) > 0 {
+				mm.response.Header.Add("Vary", "Accept")
+			}
+		}
+	}
+}
+
+// negotiate builds the mocks for the media types of the spec based on m,
+// headers are the mocked headers specified by the user.
+func (f *Fallback) negotiate(m *mock, headers map[string]string) {
+	if len(f.spec.ContentTypes) == 0 {
+		return
+	}
+
+	m.negotiated = map[string]*mock{}
+	for mediaType, ct := range f.spec.ContentTypes {
+		// keys are canonicalized, so the user specified headers always
+		// override the Content-Type of the media type.
+		merged := map[string]string{"Content-Type": mediaType}
+		for key, value := range canonicalHeaders(headers) {
+			merged[key] = value
+		}
+		for key, value := range canonicalHeaders(ct.MockHeaders) {
+			merged[key] = value
+		}
+
+		// the media type has been validated in Spec.Validate.
+		t, _, _ := mime.ParseMediaType(mediaType)
+		m.negotiated[t] = newMock(m.code, m.weight, merged, ct.MockBody)
+		m.mediaTypes = append(m.mediaTypes, t)
+	}
+	sort.Strings(m.mediaTypes)
+}
+
+func (m *mock) mocksOfMediaTypes() []*mock {
+	mocks := make([]*mock, 0, len(m.mediaTypes))
+	for _, t := range m.mediaTypes {
+		mocks = append(mocks, m.negotiated[t])
+	}
+	return mocks
+}
+
+// selectByAccept selects the mock of the media type accepted by the client,
+// it returns m itself if no media type is accepted.
+func (m *mock) selectByAccept(accept string) *mock {
+	if len(m.negotiated) == 0 || accept == "" {
+		return m
+	}
+
+	for _, r := range parseAccept(accept) {
+		switch {
+		case r == "*/*":
+			return m
+		case strings.HasSuffix(r, "/*"):
+			prefix := strings.TrimSuffix(r, "*")
+			for _, t := range m.mediaTypes {
+				if strings.HasPrefix(t, prefix) {
+					return m.negotiated[t]
+				}
+			}
+		default:
+			if n, ok := m.negotiated[r]; ok {
+				return n
+			}
+		}
+	}
+	return m
+}
+
+// parseAccept returns the media ranges of an Accept header in the order of
+// preference, media ranges with zero quality are excluded.
+func parseAccept(accept string) []string {
+	type mediaRange struct {
+		value string
+		q     float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		t, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{value: t, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	result := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		result = append(result, r.value)
+	}
+	return result
+}
+
+func newMock(code, weight int, headers map[string]string, body string) *mock {
+	m := &mock{
+		code:            code,
+		weight:          weight,
+		headers:         canonicalHeaders(headers),
+		body:            []byte(body),
+		headerTemplates: map[string]*template.Template{},
+	}
+	m.bodyLength = strconv.Itoa(len(m.body))
+
+	header := http.Header{}
+	header.Set("Content-Length", m.bodyLength)
+	for key, value := range m.headers {
+		if isTemplate(value) {
+			// the template has been validated in Spec.Validate.
+			m.headerTemplates[key] = template.Must(newTemplate(value))
+			continue
+		}
+		header.Set(key, value)
+	}
+	m.response = &MockedResponse{
+		StatusCode: code,
+		Header:     header,
+		BodyLength: len(m.body),
+	}
+
+	return m
+}
+
+// canonicalHeaders returns a copy of headers with canonical keys, so that
+// headers specified in different cases can be merged deterministically.
+func canonicalHeaders(headers map[string]string) map[string]string {
+	result := make(map[string]string, len(headers))
+	for key, value := range headers {
+		result[http.CanonicalHeaderKey(key)] = value
+	}
+	return result
+}
+
+const problemDetailsContentType = "application/problem+json"
+
+// headers returns a copy of headers with the Content-Type of problem details,
+// unless it is already specified.
+func (pd *ProblemDetails) headers(headers map[string]string) map[string]string {
+	result := canonicalHeaders(headers)
+	if _, ok := result["Content-Type"]; !ok {
+		result["Content-Type"] = problemDetailsContentType
+	}
+	return result
+}
+
+// body returns the problem details in JSON with status.
+func (pd *ProblemDetails) body(status int) string {
+	type problemDetails struct {
+		ProblemDetails
+		Status int `json:"status,omitempty"`
+	}
+	return string(codectool.MustMarshalJSON(&problemDetails{*pd, status}))
+}
+
+// pickMock selects a mock randomly by weights.
+func (f *Fallback) pickMock() *mock {
+	if len(f.mocks) == 1 {
+		return f.mocks[0]
+	}
+
+	n := f.intn(f.totalWeight)
+	for _, m := range f.mocks {
+		if n < m.weight {
+			return m
+		}
+		n -= m.weight
+	}
+	return f.mocks[len(f.mocks)-1]
+}
+
+// LastResponse returns the most recent response written by Fallback, or nil
+// if Fallback has not handled any request yet. The caller should not modify
+// the return value.
+func (f *Fallback) LastResponse() *MockedResponse {
+	return f.lastResponse.Load()
 }
 
 // Handle fallbacks HTTPContext.
@@ -107,10 +506,39 @@ func (f *Fallback) Handle(ctx *context.Context) string {
 		return resultResponseNotFound
 	}
 
-	resp.SetStatusCode(f.spec.MockCode)
-	resp.HTTPHeader().Set("Content-Length", f.bodyLength)
-	for key, value := range f.spec.MockHeaders {
-		resp.HTTPHeader().Set(key, value)
+	m := f.pickMock()
+	if req, ok := ctx.GetInputRequest().(*httpprot.Request); ok && req != nil {
+		m = m.selectByAccept(req.HTTPHeader().Get("Accept"))
+	}
+
+	code := m.code
+	if f.spec.PreserveStatus && resp.StatusCode() >= http.StatusBadRequest {
+		code = resp.StatusCode()
+	}
+	resp.SetStatusCode(code)
+	f.metricsSink().IncFallback(code)
+	resp.HTTPHeader().Set("Content-Length", m.bodyLength)
+	for key, value := range m.headers {
+		if _, ok := m.headerTemplates[key]; !ok {
+			resp.HTTPHeader().Set(key, value)
+		}
+	}
+
+	if f.spec.CloseConnection {
+		resp.HTTPHeader().Set("Connection", "close")
+	}
+	if len(f.spec.ContentTypes) > 0 {
+		resp.HTTPHeader().Add("Vary", "Accept")
+	}
+
+	mocked := m.response
+	if len(m.headerTemplates) > 0 {
+		mocked = f.setTemplateHeaders(ctx, resp, m)
+	}
+	if mocked.StatusCode != code {
+		copied := *mocked
+		copied.StatusCode = code
+		mocked = &copied
 	}
 
 	if resp.IsStream() {
@@ -119,10 +547,147 @@ func (f *Fallback) Handle(ctx *context.Context) string {
 		}
 	}
 
-	resp.SetPayload(f.mockBody)
+	if f.spec.Echo {
+		if req, ok := ctx.GetInputRequest().(*httpprot.Request); ok && req != nil {
+			f.lastResponse.Store(f.echo(req, resp, mocked))
+			return resultFallback
+		}
+	}
+
+	body := m.body
+	if m.problemDetails != nil && code != m.code {
+		copied := *mocked
+		copied.Header = mocked.Header.Clone()
+
+		body = []byte(m.problemDetails.body(code))
+		setBodyLength(resp, &copied, len(body))
+		mocked = &copied
+	}
+	if cached, ok := f.getCachedBody(ctx); ok {
+		copied := *mocked
+		copied.Header = mocked.Header.Clone()
+
+		body = f.truncate(resp, &copied, cached)
+		setBodyLength(resp, &copied, len(body))
+		mocked = &copied
+	}
+
+	resp.SetPayload(body)
+	f.lastResponse.Store(mocked)
 	return resultFallback
 }
 
+// setBodyLength sets the Content-Length of resp and mocked to n.
+func setBodyLength(resp *httpprot.Response, mocked *MockedResponse, n int) {
+	bodyLength := strconv.Itoa(n)
+	resp.HTTPHeader().Set("Content-Length", bodyLength)
+	mocked.Header.Set("Content-Length", bodyLength)
+	mocked.BodyLength = n
+}
+
+// echo sets the body of req to resp, along with headers describing req, it
+// returns the mocked response with these headers.
+func (f *Fallback) echo(req *httpprot.Request, resp *httpprot.Response, mocked *MockedResponse) *MockedResponse {
+	copied := *mocked
+	copied.Header = mocked.Header.Clone()
+
+	header := map[string]string{
+		"X-Echo-Method": req.Method(),
+		"X-Echo-Path":   req.Path(),
+	}
+	if ct := req.HTTPHeader().Get("Content-Type"); ct != "" {
+		header["Content-Type"] = ct
+	}
+	for key, value := range header {
+		resp.HTTPHeader().Set(key, value)
+		copied.Header.Set(key, value)
+	}
+
+	if req.IsStream() && f.spec.MaxBodyBytes <= 0 {
+		resp.SetPayload(req.GetPayload())
+		copied.Header.Del("Content-Length")
+		copied.BodyLength = -1
+		return &copied
+	}
+
+	var body []byte
+	if req.IsStream() {
+		var err error
+		body, err = io.ReadAll(httpprot.LimitedReader(req.GetPayload(), f.spec.MaxBodyBytes))
+		if err == httpprot.ErrBodyTooLarge {
+			setTruncated(resp, &copied)
+		} else if err != nil {
+			logger.Warnf("Fallback(%s): failed to read request body: %v", f.Name(), err)
+		}
+	} else {
+		body = f.truncate(resp, &copied, req.RawPayload())
+	}
+
+	resp.SetPayload(body)
+	copied.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	copied.BodyLength = len(body)
+	return &copied
+}
+
+// truncate truncates the dynamic body to MaxBodyBytes, and sets the header
+// X-Fallback-Truncated to resp and mocked if it is truncated.
+func (f *Fallback) truncate(resp *httpprot.Response, mocked *MockedResponse, body []byte) []byte {
+	if f.spec.MaxBodyBytes <= 0 || int64(len(body)) <= f.spec.MaxBodyBytes {
+		return body
+	}
+	setTruncated(resp, mocked)
+	return body[:f.spec.MaxBodyBytes]
+}
+
+// setTruncated sets the header X-Fallback-Truncated to resp and mocked.
+func setTruncated(resp *httpprot.Response, mocked *MockedResponse) {
+	resp.HTTPHeader().Set(truncatedHeaderKey, "true")
+	mocked.Header.Set(truncatedHeaderKey, "true")
+}
+
+// getCachedBody returns the last known good body of the request from the
+// configured response cache.
+func (f *Fallback) getCachedBody(ctx *context.Context) ([]byte, bool) {
+	if f.spec.ResponseCache == "" {
+		return nil, false
+	}
+	cache := getResponseCache(f.spec.ResponseCache)
+	if cache == nil {
+		return nil, false
+	}
+	req, ok := ctx.GetInputRequest().(*httpprot.Request)
+	if !ok || req == nil {
+		return nil, false
+	}
+	return cache.Get(CacheKey(req))
+}
+
+// setTemplateHeaders evaluates the header templates of m and sets the results
+// to resp, it returns the mocked response with the evaluated headers.
+func (f *Fallback) setTemplateHeaders(ctx *context.Context, resp *httpprot.Response, m *mock) *MockedResponse {
+	data, err := builder.PrepareBuilderData(ctx)
+	if err != nil {
+		logger.Warnf("Fallback(%s): failed to prepare template data: %v", f.Name(), err)
+		return m.response
+	}
+
+	mocked := *m.response
+	mocked.Header = m.response.Header.Clone()
+
+	var buf bytes.Buffer
+	for key, t := range m.headerTemplates {
+		buf.Reset()
+		if err := t.Execute(&buf, data); err != nil {
+			logger.Warnf("Fallback(%s): failed to execute template of header %s: %v", f.Name(), key, err)
+			continue
+		}
+		resp.HTTPHeader().Set(key, buf.String())
+		mocked.Header.Set(key, buf.String())
+	}
+
+	return &mocked
+}
+
 // Status returns Status.
 func (f *Fallback) Status() interface{} {
 	return nil
@@ -18,7 +18,12 @@
 package fallback
 
 import (
+	"bufio"
 	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/megaease/easegress/v2/pkg/context"
@@ -58,7 +63,13 @@ mockBody: "mocked body"
 	assert.Nil(err)
 	ctx.SetInputResponse(resp)
 
+	assert.Nil(fb.(*Fallback).LastResponse())
 	fb.Handle(ctx)
+	last := fb.(*Fallback).LastResponse()
+	assert.NotNil(last)
+	assert.Equal(203, last.StatusCode)
+	assert.Equal("yes", last.Header.Get("X-Mocked"))
+	assert.Equal(len("mocked body"), last.BodyLength)
 	if resp.StatusCode() != 203 {
 		t.Error("status code is not correct")
 	}
@@ -98,3 +109,636 @@ mockBody: "mocked body"
 		t.Error("header is not correct")
 	}
 }
+
+// createFallback creates a Fallback from yamlConfig, it also returns the raw
+// spec, so that tests can create variants of the Fallback by newFallback.
+func createFallback(yamlConfig string) (*Fallback, map[string]interface{}, error) {
+	rawSpec := make(map[string]interface{})
+	codectool.MustUnmarshal([]byte(yamlConfig), &rawSpec)
+	fb, err := newFallback(rawSpec, nil)
+	return fb, rawSpec, err
+}
+
+// newFallback creates a Fallback from rawSpec, it inherits prev if prev is
+// not nil.
+func newFallback(rawSpec map[string]interface{}, prev *Fallback) (*Fallback, error) {
+	spec, err := filters.NewSpec(nil, "", rawSpec)
+	if err != nil {
+		return nil, err
+	}
+	fb := kind.CreateInstance(spec).(*Fallback)
+	if prev == nil {
+		fb.Init()
+	} else {
+		fb.Inherit(prev)
+	}
+	return fb, nil
+}
+
+func TestTemplateHeaders(t *testing.T) {
+	assert := assert.New(t)
+	const yamlConfig = `
+kind: Fallback
+name: fallback
+mockCode: 503
+mockHeaders:
+  X-Static: static
+  X-Request-Id: '{{ .req.Header.Get "X-Request-Id" }}'
+  X-Maintenance-Until: '{{ .data.until }}'
+  X-Namespace: '{{ .namespace }}'
+  X-Default-Request-Id: '{{ .requests.DEFAULT.Header.Get "X-Request-Id" }}'
+`
+	fb, rawSpec, err := createFallback(yamlConfig)
+	assert.Nil(err)
+
+	ctx := context.New(tracing.NoopSpan)
+	req, _ := httpprot.NewRequest(nil)
+	req.HTTPHeader().Set("X-Request-Id", "abc")
+	ctx.SetInputRequest(req)
+	ctx.SetData("until", "tomorrow")
+	resp, _ := httpprot.NewResponse(nil)
+	ctx.SetInputResponse(resp)
+
+	assert.Equal(resultFallback, fb.Handle(ctx))
+	assert.Equal("static", resp.Header().Get("X-Static"))
+	assert.Equal("abc", resp.Header().Get("X-Request-Id"))
+	assert.Equal("tomorrow", resp.Header().Get("X-Maintenance-Until"))
+	assert.Equal(context.DefaultNamespace, resp.Header().Get("X-Namespace"))
+	assert.Equal("abc", resp.Header().Get("X-Default-Request-Id"))
+	assert.Equal("abc", fb.LastResponse().Header.Get("X-Request-Id"))
+	assert.Empty(fb.mocks[0].response.Header.Get("X-Request-Id"))
+
+	rawSpec["mockHeaders"] = map[string]interface{}{"X-Bad": "{{ .req"}
+	_, err = filters.NewSpec(nil, "", rawSpec)
+	assert.NotNil(err)
+}
+
+func TestVariants(t *testing.T) {
+	assert := assert.New(t)
+	const yamlConfig = `
+kind: Fallback
+name: fallback
+mockCode: 200
+variants:
+- weight: 1
+  mockCode: 503
+  mockBody: "page A"
+- weight: 3
+  mockCode: 502
+  mockHeaders:
+    X-Variant: B
+  mockBody: "page B"
+`
+	fb, rawSpec, err := createFallback(yamlConfig)
+	assert.Nil(err)
+	assert.Equal(4, fb.totalWeight)
+
+	handle := func(n int) *httpprot.Response {
+		fb.intn = func(int) int { return n }
+		ctx := context.New(tracing.NoopSpan)
+		resp, _ := httpprot.NewResponse(nil)
+		ctx.SetInputResponse(resp)
+		assert.Equal(resultFallback, fb.Handle(ctx))
+		return resp
+	}
+
+	resp := handle(0)
+	assert.Equal(503, resp.StatusCode())
+	assert.Equal("page A", string(resp.RawPayload()))
+	assert.Empty(resp.Header().Get("X-Variant"))
+
+	for _, n := range []int{1, 2, 3} {
+		resp = handle(n)
+		assert.Equal(502, resp.StatusCode())
+		assert.Equal("page B", string(resp.RawPayload()))
+		assert.Equal("B", resp.Header().Get("X-Variant"))
+		assert.Equal(502, fb.LastResponse().StatusCode)
+	}
+
+	// the real random source selects both variants.
+	fb.reload()
+	counts := map[int]int{}
+	for i := 0; i < 1000; i++ {
+		ctx := context.New(tracing.NoopSpan)
+		resp, _ := httpprot.NewResponse(nil)
+		ctx.SetInputResponse(resp)
+		fb.Handle(ctx)
+		counts[resp.StatusCode()]++
+	}
+	assert.Greater(counts[502], counts[503])
+	assert.NotZero(counts[503])
+
+	// the same seed selects the same sequence of variants, even after
+	// reloading.
+	rawSpec["seed"] = 42
+	sequence := func(fb *Fallback) []int {
+		var codes []int
+		for i := 0; i < 20; i++ {
+			ctx := context.New(tracing.NoopSpan)
+			resp, _ := httpprot.NewResponse(nil)
+			ctx.SetInputResponse(resp)
+			fb.Handle(ctx)
+			codes = append(codes, resp.StatusCode())
+		}
+		return codes
+	}
+	fb, err = newFallback(rawSpec, nil)
+	assert.Nil(err)
+	expected := sequence(fb)
+	assert.Contains(expected, 502)
+	assert.Contains(expected, 503)
+
+	fb2, err := newFallback(rawSpec, fb)
+	assert.Nil(err)
+	assert.Equal(expected, sequence(fb2))
+}
+
+func TestCloseConnection(t *testing.T) {
+	assert := assert.New(t)
+	const yamlConfig = `
+kind: Fallback
+name: fallback
+mockCode: 503
+closeConnection: true
+`
+	fb, rawSpec, err := createFallback(yamlConfig)
+	assert.Nil(err)
+
+	ctx := context.New(tracing.NoopSpan)
+	resp, _ := httpprot.NewResponse(nil)
+	ctx.SetInputResponse(resp)
+	fb.Handle(ctx)
+	assert.Equal("close", resp.Header().Get("Connection"))
+	assert.Equal("close", fb.LastResponse().Header.Get("Connection"))
+
+	delete(rawSpec, "closeConnection")
+	fb, err = newFallback(rawSpec, nil)
+	assert.Nil(err)
+	resp, _ = httpprot.NewResponse(nil)
+	ctx.SetInputResponse(resp)
+	fb.Handle(ctx)
+	assert.Empty(resp.Header().Get("Connection"))
+}
+
+type mapCache map[string][]byte
+
+func (c mapCache) Get(key string) ([]byte, bool) {
+	body, ok := c[key]
+	return body, ok
+}
+
+func TestResponseCache(t *testing.T) {
+	assert := assert.New(t)
+	const yamlConfig = `
+kind: Fallback
+name: fallback
+mockCode: 200
+mockBody: mocked
+responseCache: test-cache
+`
+	fb, _, err := createFallback(yamlConfig)
+	assert.Nil(err)
+
+	handle := func(url string) *httpprot.Response {
+		ctx := context.New(tracing.NoopSpan)
+		stdr, _ := http.NewRequest(http.MethodGet, url, nil)
+		req, _ := httpprot.NewRequest(stdr)
+		ctx.SetInputRequest(req)
+		resp, _ := httpprot.NewResponse(nil)
+		ctx.SetInputResponse(resp)
+		fb.Handle(ctx)
+		return resp
+	}
+
+	// the cache is not registered.
+	resp := handle("http://127.0.0.1/cached")
+	assert.Equal("mocked", string(resp.RawPayload()))
+
+	RegisterResponseCache("test-cache", mapCache{
+		"GET 127.0.0.1/cached":       []byte("last good"),
+		"GET a.example.com/page?q=1": []byte("page of a"),
+		"GET b.example.com/page?q=1": []byte("page of b"),
+	})
+	defer UnregisterResponseCache("test-cache")
+
+	resp = handle("http://127.0.0.1/cached")
+	assert.Equal("last good", string(resp.RawPayload()))
+	assert.Equal("9", resp.Header().Get("Content-Length"))
+	assert.Equal(9, fb.LastResponse().BodyLength)
+	assert.Equal("6", fb.mocks[0].response.Header.Get("Content-Length"))
+
+	// cache miss
+	resp = handle("http://127.0.0.1/missed")
+	assert.Equal("mocked", string(resp.RawPayload()))
+	assert.Equal("6", resp.Header().Get("Content-Length"))
+
+	// requests received by a server have no host in their URLs, virtual
+	// hosts don't share the cached bodies.
+	for host, expected := range map[string]string{
+		"a.example.com": "page of a",
+		"b.example.com": "page of b",
+		"c.example.com": "mocked",
+	} {
+		raw := "GET /page?q=1 HTTP/1.1\r\nHost: " + host + "\r\n\r\n"
+		stdr, err := http.ReadRequest(bufio.NewReader(strings.NewReader(raw)))
+		assert.Nil(err)
+		assert.Empty(stdr.URL.Host)
+		req, _ := httpprot.NewRequest(stdr)
+		ctx := context.New(tracing.NoopSpan)
+		ctx.SetInputRequest(req)
+		resp, _ := httpprot.NewResponse(nil)
+		ctx.SetInputResponse(resp)
+		fb.Handle(ctx)
+		assert.Equal(expected, string(resp.RawPayload()), host)
+	}
+}
+
+func TestContentTypes(t *testing.T) {
+	assert := assert.New(t)
+	const yamlConfig = `
+kind: Fallback
+name: fallback
+mockCode: 503
+mockHeaders:
+  X-Fallback: "true"
+mockBody: default
+contentTypes:
+  text/html:
+    mockBody: <p>unavailable</p>
+  application/json:
+    mockHeaders:
+      content-type: application/json; charset=utf-8
+    mockBody: '{"error": "unavailable"}'
+`
+	fb, rawSpec, err := createFallback(yamlConfig)
+	assert.Nil(err)
+
+	handle := func(accept string) *httpprot.Response {
+		ctx := context.New(tracing.NoopSpan)
+		req, _ := httpprot.NewRequest(nil)
+		if accept != "" {
+			req.HTTPHeader().Set("Accept", accept)
+		}
+		ctx.SetInputRequest(req)
+		resp, _ := httpprot.NewResponse(nil)
+		ctx.SetInputResponse(resp)
+		fb.Handle(ctx)
+		return resp
+	}
+
+	for accept, expected := range map[string]string{
+		"":                                  "default",
+		"image/png":                         "default",
+		"*/*":                               "default",
+		"text/html,application/xhtml+xml":   "<p>unavailable</p>",
+		"text/*":                            "<p>unavailable</p>",
+		"application/json":                  `{"error": "unavailable"}`,
+		"text/html;q=0.5, application/json": `{"error": "unavailable"}`,
+		"application/json;q=0, */*;q=0.1":   "default",
+	} {
+		resp := handle(accept)
+		assert.Equal(503, resp.StatusCode(), accept)
+		assert.Equal(expected, string(resp.RawPayload()), accept)
+		assert.Equal("true", resp.Header().Get("X-Fallback"), accept)
+		assert.Equal("Accept", resp.Header().Get("Vary"), accept)
+	}
+
+	resp := handle("text/html")
+	assert.Equal("text/html", resp.Header().Get("Content-Type"))
+	// the user specified header always wins regardless of its case.
+	for i := 0; i < 10; i++ {
+		resp = handle("application/json")
+		assert.Equal([]string{"application/json; charset=utf-8"}, resp.HTTPHeader().Values("Content-Type"))
+		assert.Equal("application/json; charset=utf-8", fb.LastResponse().Header.Get("Content-Type"))
+	}
+
+	rawSpec["contentTypes"] = map[string]interface{}{"html": map[string]interface{}{}}
+	_, err = filters.NewSpec(nil, "", rawSpec)
+	assert.NotNil(err)
+
+	for _, duplicated := range []string{"TEXT/HTML", "text/html; charset=utf-8"} {
+		rawSpec["contentTypes"] = map[string]interface{}{
+			"text/html": map[string]interface{}{},
+			duplicated:  map[string]interface{}{},
+		}
+		_, err = filters.NewSpec(nil, "", rawSpec)
+		assert.NotNil(err, duplicated)
+	}
+}
+
+func TestEcho(t *testing.T) {
+	assert := assert.New(t)
+	const yamlConfig = `
+kind: Fallback
+name: fallback
+mockCode: 200
+mockHeaders:
+  X-Fallback: "true"
+mockBody: mocked
+echo: true
+`
+	fb, _, err := createFallback(yamlConfig)
+	assert.Nil(err)
+
+	stdr, _ := http.NewRequest(http.MethodPost, "http://127.0.0.1/echo", strings.NewReader("hello"))
+	stdr.Header.Set("Content-Type", "text/plain")
+	req, _ := httpprot.NewRequest(stdr)
+	assert.Nil(req.FetchPayload(0))
+
+	ctx := context.New(tracing.NoopSpan)
+	ctx.SetInputRequest(req)
+	resp, _ := httpprot.NewResponse(nil)
+	ctx.SetInputResponse(resp)
+	assert.Equal(resultFallback, fb.Handle(ctx))
+
+	assert.Equal(200, resp.StatusCode())
+	assert.Equal("hello", string(resp.RawPayload()))
+	assert.Equal("5", resp.Header().Get("Content-Length"))
+	assert.Equal("text/plain", resp.Header().Get("Content-Type"))
+	assert.Equal(http.MethodPost, resp.Header().Get("X-Echo-Method"))
+	assert.Equal("/echo", resp.Header().Get("X-Echo-Path"))
+	assert.Equal("true", resp.Header().Get("X-Fallback"))
+
+	mocked := fb.LastResponse()
+	assert.Equal(5, mocked.BodyLength)
+	assert.Equal("/echo", mocked.Header.Get("X-Echo-Path"))
+	assert.Empty(fb.mocks[0].response.Header.Get("X-Echo-Path"))
+
+	// stream
+	stdr, _ = http.NewRequest(http.MethodPut, "http://127.0.0.1/stream", strings.NewReader("stream"))
+	req, _ = httpprot.NewRequest(stdr)
+	assert.Nil(req.FetchPayload(-1))
+	ctx.SetInputRequest(req)
+	resp, _ = httpprot.NewResponse(nil)
+	ctx.SetInputResponse(resp)
+	fb.Handle(ctx)
+
+	assert.True(resp.IsStream())
+	data, _ := io.ReadAll(resp.GetPayload())
+	assert.Equal("stream", string(data))
+	assert.Empty(resp.Header().Get("Content-Length"))
+	assert.Equal(-1, fb.LastResponse().BodyLength)
+}
+
+func TestProblemDetails(t *testing.T) {
+	assert := assert.New(t)
+	const yamlConfig = `
+kind: Fallback
+name: fallback
+mockCode: 503
+problemDetails:
+  type: https://example.com/probs/unavailable
+  title: Service Unavailable
+  detail: the service is degraded
+  instance: /orders
+`
+	fb, rawSpec, err := createFallback(yamlConfig)
+	assert.Nil(err)
+
+	ctx := context.New(tracing.NoopSpan)
+	resp, _ := httpprot.NewResponse(nil)
+	ctx.SetInputResponse(resp)
+	fb.Handle(ctx)
+
+	assert.Equal(503, resp.StatusCode())
+	assert.Equal("application/problem+json", resp.Header().Get("Content-Type"))
+	body := map[string]interface{}{}
+	codectool.MustUnmarshalJSON(resp.RawPayload(), &body)
+	assert.Equal(map[string]interface{}{
+		"type":     "https://example.com/probs/unavailable",
+		"title":    "Service Unavailable",
+		"status":   float64(503),
+		"detail":   "the service is degraded",
+		"instance": "/orders",
+	}, body)
+
+	rawSpec["mockHeaders"] = map[string]interface{}{"content-type": "application/json"}
+	fb, err = newFallback(rawSpec, nil)
+	assert.Nil(err)
+	resp, _ = httpprot.NewResponse(nil)
+	ctx.SetInputResponse(resp)
+	fb.Handle(ctx)
+	assert.Equal([]string{"application/json"}, resp.HTTPHeader().Values("Content-Type"))
+
+	// the Content-Type of problem details is not applied to other media types.
+	delete(rawSpec, "mockHeaders")
+	rawSpec["contentTypes"] = map[string]interface{}{
+		"text/html": map[string]interface{}{"mockBody": "<p>unavailable</p>"},
+	}
+	fb, err = newFallback(rawSpec, nil)
+	assert.Nil(err)
+	handle := func(accept string) *httpprot.Response {
+		req, _ := httpprot.NewRequest(nil)
+		req.HTTPHeader().Set("Accept", accept)
+		ctx.SetInputRequest(req)
+		resp, _ := httpprot.NewResponse(nil)
+		ctx.SetInputResponse(resp)
+		fb.Handle(ctx)
+		return resp
+	}
+	resp = handle("text/html")
+	assert.Equal([]string{"text/html"}, resp.HTTPHeader().Values("Content-Type"))
+	assert.Equal("<p>unavailable</p>", string(resp.RawPayload()))
+	resp = handle("application/json")
+	assert.Equal([]string{"application/problem+json"}, resp.HTTPHeader().Values("Content-Type"))
+	assert.Contains(string(resp.RawPayload()), "Service Unavailable")
+	delete(rawSpec, "contentTypes")
+
+	rawSpec["mockBody"] = "body"
+	_, err = filters.NewSpec(nil, "", rawSpec)
+	assert.NotNil(err)
+
+	delete(rawSpec, "mockBody")
+	rawSpec["variants"] = []interface{}{
+		map[string]interface{}{"weight": 1, "mockCode": 503},
+	}
+	_, err = filters.NewSpec(nil, "", rawSpec)
+	assert.NotNil(err)
+}
+
+func TestPreserveStatus(t *testing.T) {
+	assert := assert.New(t)
+	const yamlConfig = `
+kind: Fallback
+name: fallback
+mockCode: 503
+mockBody: friendly
+preserveStatus: true
+`
+	fb, _, err := createFallback(yamlConfig)
+	assert.Nil(err)
+
+	ctx := context.New(tracing.NoopSpan)
+	resp, _ := httpprot.NewResponse(&http.Response{StatusCode: 502, Header: http.Header{}, Body: http.NoBody})
+	ctx.SetInputResponse(resp)
+	fb.Handle(ctx)
+	assert.Equal(502, resp.StatusCode())
+	assert.Equal("friendly", string(resp.RawPayload()))
+	assert.Equal(502, fb.LastResponse().StatusCode)
+	assert.Equal(503, fb.mocks[0].response.StatusCode)
+
+	// the default response of a pipeline is 200, which is not kept.
+	resp, _ = httpprot.NewResponse(nil)
+	assert.Equal(http.StatusOK, resp.StatusCode())
+	ctx.SetInputResponse(resp)
+	fb.Handle(ctx)
+	assert.Equal(503, resp.StatusCode())
+	assert.Equal(503, fb.LastResponse().StatusCode)
+
+	resp, _ = httpprot.NewResponse(nil)
+	resp.SetStatusCode(http.StatusNotFound)
+	ctx.SetInputResponse(resp)
+	fb.Handle(ctx)
+	assert.Equal(404, resp.StatusCode())
+}
+
+func TestPreserveStatusWithProblemDetails(t *testing.T) {
+	assert := assert.New(t)
+	const yamlConfig = `
+kind: Fallback
+name: fallback
+mockCode: 503
+preserveStatus: true
+problemDetails:
+  title: Service Unavailable
+contentTypes:
+  text/html:
+    mockBody: <p>unavailable</p>
+`
+	fb, _, err := createFallback(yamlConfig)
+	assert.Nil(err)
+
+	handle := func(code int) (*httpprot.Response, map[string]interface{}) {
+		ctx := context.New(tracing.NoopSpan)
+		resp, _ := httpprot.NewResponse(nil)
+		resp.SetStatusCode(code)
+		ctx.SetInputResponse(resp)
+		fb.Handle(ctx)
+		body := map[string]interface{}{}
+		codectool.MustUnmarshalJSON(resp.RawPayload(), &body)
+		return resp, body
+	}
+
+	// the status in the body matches the preserved status code.
+	resp, body := handle(http.StatusBadGateway)
+	assert.Equal(502, resp.StatusCode())
+	assert.Equal(float64(502), body["status"])
+	assert.Equal(strconv.Itoa(len(resp.RawPayload())), resp.Header().Get("Content-Length"))
+	assert.Equal(502, fb.LastResponse().StatusCode)
+
+	// the mocked body is not modified.
+	resp, body = handle(http.StatusOK)
+	assert.Equal(503, resp.StatusCode())
+	assert.Equal(float64(503), body["status"])
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	assert := assert.New(t)
+	const yamlConfig = `
+kind: Fallback
+name: fallback
+mockCode: 200
+mockBody: mocked body
+echo: true
+maxBodyBytes: 5
+`
+	fb, rawSpec, err := createFallback(yamlConfig)
+	assert.Nil(err)
+
+	handle := func(body string, stream bool) *httpprot.Response {
+		stdr, _ := http.NewRequest(http.MethodPost, "http://127.0.0.1/echo", strings.NewReader(body))
+		req, _ := httpprot.NewRequest(stdr)
+		if stream {
+			req.FetchPayload(-1)
+		} else {
+			req.FetchPayload(0)
+		}
+		ctx := context.New(tracing.NoopSpan)
+		ctx.SetInputRequest(req)
+		resp, _ := httpprot.NewResponse(nil)
+		ctx.SetInputResponse(resp)
+		fb.Handle(ctx)
+		return resp
+	}
+
+	for _, stream := range []bool{false, true} {
+		resp := handle("hello world", stream)
+		assert.Equal("hello", string(resp.RawPayload()))
+		assert.Equal("5", resp.Header().Get("Content-Length"))
+		assert.Equal("true", resp.Header().Get("X-Fallback-Truncated"))
+		assert.Equal(5, fb.LastResponse().BodyLength)
+		assert.Equal("true", fb.LastResponse().Header.Get("X-Fallback-Truncated"))
+
+		resp = handle("hello", stream)
+		assert.Equal("hello", string(resp.RawPayload()))
+		assert.Empty(resp.Header().Get("X-Fallback-Truncated"))
+	}
+
+	// cached body
+	delete(rawSpec, "echo")
+	rawSpec["responseCache"] = "truncate-cache"
+	fb, err = newFallback(rawSpec, nil)
+	assert.Nil(err)
+
+	RegisterResponseCache("truncate-cache", mapCache{
+		"POST 127.0.0.1/echo": []byte("cached body"),
+	})
+	defer UnregisterResponseCache("truncate-cache")
+
+	resp := handle("", false)
+	assert.Equal("cache", string(resp.RawPayload()))
+	assert.Equal("true", resp.Header().Get("X-Fallback-Truncated"))
+	assert.Empty(fb.mocks[0].response.Header.Get("X-Fallback-Truncated"))
+}
+
+type codeRecorder struct {
+	mu    sync.Mutex
+	codes []int
+}
+
+func (r *codeRecorder) IncFallback(code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codes = append(r.codes, code)
+}
+
+func TestMetricsSink(t *testing.T) {
+	assert := assert.New(t)
+	const yamlConfig = `
+kind: Fallback
+name: fallback
+mockCode: 503
+preserveStatus: true
+`
+	fb, rawSpec, err := createFallback(yamlConfig)
+	assert.Nil(err)
+	assert.Equal(NopMetricsSink{}, fb.metricsSink())
+
+	handle := func(fb *Fallback, code int) {
+		ctx := context.New(tracing.NoopSpan)
+		resp, _ := httpprot.NewResponse(nil)
+		resp.SetStatusCode(code)
+		ctx.SetInputResponse(resp)
+		fb.Handle(ctx)
+	}
+
+	// the default sink does nothing.
+	handle(fb, http.StatusOK)
+
+	sink := &codeRecorder{}
+	fb.SetMetricsSink(sink)
+	handle(fb, http.StatusOK)
+	handle(fb, http.StatusBadGateway)
+	assert.Equal([]int{503, 502}, sink.codes)
+
+	// the sink is kept by the new generation.
+	fb2, err := newFallback(rawSpec, fb)
+	assert.Nil(err)
+	handle(fb2, http.StatusOK)
+	assert.Equal([]int{503, 502, 503}, sink.codes)
+
+	fb2.SetMetricsSink(nil)
+	assert.Equal(NopMetricsSink{}, fb2.metricsSink())
+	handle(fb2, http.StatusOK)
+	assert.Len(sink.codes, 3)
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2017, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fallback
+
+// MetricsSink receives the activations of Fallback, it decouples Fallback
+// from any specific metrics backend.
+type MetricsSink interface {
+	// IncFallback is called once for every request handled by Fallback,
+	// with the status code of the fallback response.
+	IncFallback(code int)
+}
+
+// NopMetricsSink is a MetricsSink which does nothing, it is the default
+// MetricsSink of Fallback.
+type NopMetricsSink struct{}
+
+// IncFallback implements MetricsSink.
+func (NopMetricsSink) IncFallback(code int) {}
+
+// metricsSinkHolder makes MetricsSink storable in an atomic.Pointer.
+type metricsSinkHolder struct {
+	sink MetricsSink
+}
+
+// SetMetricsSink sets the MetricsSink of f, a nil sink resets it to the
+// default NopMetricsSink. It is safe to call it while f is handling requests,
+// and the sink is kept when f is inherited by a new generation.
+func (f *Fallback) SetMetricsSink(sink MetricsSink) {
+	if sink == nil {
+		sink = NopMetricsSink{}
+	}
+	f.metrics.Store(&metricsSinkHolder{sink: sink})
+}
+
+func (f *Fallback) metricsSink() MetricsSink {
+	if h := f.metrics.Load(); h != nil {
+		return h.sink
+	}
+	return NopMetricsSink{}
+}
@@ -48,6 +48,13 @@ type (
 		// function should always return a new spec copy, because the caller
 		// may modify the returned spec.
 		DefaultSpec func() Spec
+
+		// Before lists the names of the filter kinds which should run after
+		// filters of this kind in the same pipeline, e.g. a filter extracting
+		// client identities should run before authorization filters. It is
+		// only a hint, a pipeline reports a warning if its flow contradicts
+		// the hint.
+		Before []string
 	}
 
 	// Filter is the interface of filters handling traffic of various protocols.
@@ -118,6 +125,17 @@ type (
 	}
 )
 
+// ShouldRunBefore returns whether filters of kind k should run before filters
+// of kind other.
+func (k *Kind) ShouldRunBefore(other string) bool {
+	for _, name := range k.Before {
+		if name == other {
+			return true
+		}
+	}
+	return false
+}
+
 // NewSpec creates a filter spec and validates it.
 func NewSpec(super *supervisor.Supervisor, pipeline string, rawSpec interface{}) (spec Spec, err error) {
 	defer func() {
@@ -172,6 +190,15 @@ func NewSpec(super *supervisor.Supervisor, pipeline string, rawSpec interface{})
 	return
 }
 
+// ValidateSpec validates rawSpec without creating a filter instance. The
+// validation does not require a supervisor or any system controllers, so
+// it can be used to lint filter specs offline. Checks that depend on the
+// runtime environment are performed by Init of the filter instances.
+func ValidateSpec(rawSpec interface{}) error {
+	_, err := NewSpec(nil, "", rawSpec)
+	return err
+}
+
 // Super returns super.
 func (s *BaseSpec) Super() *supervisor.Supervisor {
 	return s.super
@@ -107,3 +107,22 @@ field: "abc"
 	assert.Nil(spec.Super())
 	assert.NotEmpty(spec.JSONConfig())
 }
+
+func TestValidateSpec(t *testing.T) {
+	assert := assert.New(t)
+
+	kinds["Mock"] = mockKind
+	defer delete(kinds, "Mock")
+
+	rawSpec := map[string]interface{}{}
+	codectool.MustUnmarshal([]byte("name: filter\nkind: Mock\nfield: abc"), &rawSpec)
+	assert.Nil(ValidateSpec(rawSpec))
+
+	rawSpec = map[string]interface{}{}
+	codectool.MustUnmarshal([]byte("name: filter\nkind: Mock\nfield: [1, 2]"), &rawSpec)
+	assert.NotNil(ValidateSpec(rawSpec), "field should be a string")
+
+	rawSpec = map[string]interface{}{}
+	codectool.MustUnmarshal([]byte("name: filter\nkind: Unknown"), &rawSpec)
+	assert.NotNil(ValidateSpec(rawSpec), "kind Unknown not exist")
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2017, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package filtertest provides utilities for testing filters, e.g. creating
+// filters with a stubbed supervisor, creating HTTP contexts and asserting on
+// the resulting responses and tags.
+package filtertest
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/megaease/easegress/v2/pkg/context"
+	"github.com/megaease/easegress/v2/pkg/filters"
+	"github.com/megaease/easegress/v2/pkg/protocols/httpprot"
+	"github.com/megaease/easegress/v2/pkg/supervisor"
+	"github.com/megaease/easegress/v2/pkg/tracing"
+	"github.com/megaease/easegress/v2/pkg/util/codectool"
+	"github.com/stretchr/testify/assert"
+)
+
+// NewFilter creates a filter from yamlConfig and initializes it. The kind
+// of the filter must have been registered, that's, the package of the
+// filter must be imported. The supervisor of the filter spec is nil, use
+// NewFilterWithSupervisor for filters depending on the supervisor.
+func NewFilter(yamlConfig string) (filters.Filter, error) {
+	return NewFilterWithSupervisor(nil, yamlConfig)
+}
+
+// NewFilterWithSupervisor is like NewFilter, but the supervisor of the
+// filter spec is super, which is usually created by NewSupervisor.
+func NewFilterWithSupervisor(super *supervisor.Supervisor, yamlConfig string) (filters.Filter, error) {
+	rawSpec := map[string]interface{}{}
+	if err := codectool.UnmarshalYAML([]byte(yamlConfig), &rawSpec); err != nil {
+		return nil, err
+	}
+
+	spec, err := filters.NewSpec(super, "", rawSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	f := filters.Create(spec)
+	f.Init()
+	return f, nil
+}
+
+// NewHTTPContext creates a context whose input request is created from stdr
+// and input response is an empty one with status code 200. A GET request
+// to "/" is used if stdr is nil.
+func NewHTTPContext(stdr *http.Request) *context.Context {
+	if stdr == nil {
+		stdr = &http.Request{Method: http.MethodGet}
+	}
+	if stdr.URL == nil {
+		stdr.URL = &url.URL{Path: "/"}
+	}
+	if stdr.Header == nil {
+		stdr.Header = http.Header{}
+	}
+	if stdr.Body == nil {
+		stdr.Body = http.NoBody
+	}
+
+	req, _ := httpprot.NewRequest(stdr)
+	req.FetchPayload(0)
+	resp, _ := httpprot.NewResponse(nil)
+
+	ctx := context.New(tracing.NoopSpan)
+	ctx.SetInputRequest(req)
+	ctx.SetInputResponse(resp)
+	return ctx
+}
+
+// Request returns the input request of ctx.
+func Request(ctx *context.Context) *httpprot.Request {
+	req, _ := ctx.GetInputRequest().(*httpprot.Request)
+	return req
+}
+
+// Response returns the input response of ctx.
+func Response(ctx *context.Context) *httpprot.Response {
+	resp, _ := ctx.GetInputResponse().(*httpprot.Response)
+	return resp
+}
+
+// ResponseBody returns the payload of the input response of ctx as a
+// string, it must not be a stream.
+func ResponseBody(ctx *context.Context) string {
+	resp := Response(ctx)
+	if resp == nil {
+		return ""
+	}
+	return string(resp.RawPayload())
+}
+
+// Tags returns the tags added to ctx by filters.
+func Tags(ctx *context.Context) []string {
+	tags := ctx.Tags()
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, " | ")
+}
+
+// AssertTag asserts that tag has been added to ctx.
+func AssertTag(t assert.TestingT, ctx *context.Context, tag string) bool {
+	return assert.Contains(t, Tags(ctx), tag)
+}
+
+// AssertResponse asserts the status code and the body of the input response
+// of ctx.
+func AssertResponse(t assert.TestingT, ctx *context.Context, code int, body string) bool {
+	resp := Response(ctx)
+	if !assert.NotNil(t, resp, "response not found") {
+		return false
+	}
+	return assert.Equal(t, code, resp.StatusCode(), "status code") &&
+		assert.Equal(t, body, ResponseBody(ctx), "body")
+}
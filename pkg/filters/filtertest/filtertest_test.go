@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2017, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filtertest_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	_ "github.com/megaease/easegress/v2/pkg/filters/certextractor"
+	_ "github.com/megaease/easegress/v2/pkg/filters/fallback"
+	"github.com/megaease/easegress/v2/pkg/filters/filtertest"
+	"github.com/megaease/easegress/v2/pkg/supervisor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := filtertest.NewFilter("kind: NotExist\nname: f")
+	assert.NotNil(err)
+
+	_, err = filtertest.NewFilter("kind: [")
+	assert.NotNil(err)
+
+	f, err := filtertest.NewFilter("kind: Fallback\nname: f\nmockCode: 503")
+	assert.Nil(err)
+	assert.Equal("f", f.Name())
+}
+
+type controller struct{}
+
+func (c *controller) Category() supervisor.ObjectCategory { return supervisor.CategorySystemController }
+func (c *controller) Kind() string                        { return "MockedController" }
+func (c *controller) DefaultSpec() interface{}            { return &struct{}{} }
+func (c *controller) Status() *supervisor.Status          { return &supervisor.Status{} }
+func (c *controller) Close()                              {}
+
+func TestNewFilterWithSupervisor(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &controller{}
+	super := filtertest.NewSupervisor(nil, map[string]supervisor.Object{c.Kind(): c})
+	assert.NotNil(super.Cluster())
+	entity, ok := super.GetSystemController(c.Kind())
+	assert.True(ok)
+	assert.Same(c, entity.Instance())
+
+	f, err := filtertest.NewFilterWithSupervisor(super, "kind: Fallback\nname: f\nmockCode: 503")
+	assert.Nil(err)
+	assert.Same(super, f.Spec().Super())
+}
+
+// failures records the failures of assertions.
+type failures []string
+
+func (f *failures) Errorf(format string, args ...interface{}) {
+	*f = append(*f, fmt.Sprintf(format, args...))
+}
+
+func TestTags(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := filtertest.NewHTTPContext(nil)
+	assert.Empty(filtertest.Tags(ctx))
+
+	ctx.AddTag("a")
+	ctx.AddTag("b")
+	assert.Equal([]string{"a", "b"}, filtertest.Tags(ctx))
+	assert.True(filtertest.AssertTag(t, ctx, "b"))
+	f := &failures{}
+	assert.False(filtertest.AssertTag(f, ctx, "c"))
+	assert.Len(*f, 1)
+}
+
+func TestAssertResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := filtertest.NewHTTPContext(nil)
+	filtertest.Response(ctx).SetStatusCode(http.StatusNotFound)
+	filtertest.Response(ctx).SetPayload("not found")
+	assert.True(filtertest.AssertResponse(t, ctx, http.StatusNotFound, "not found"))
+	f := &failures{}
+	assert.False(filtertest.AssertResponse(f, ctx, http.StatusOK, "not found"))
+	assert.False(filtertest.AssertResponse(f, ctx, http.StatusNotFound, "found"))
+	assert.Len(*f, 2)
+}
+
+func TestNewHTTPContext(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := filtertest.NewHTTPContext(nil)
+	req := filtertest.Request(ctx)
+	assert.Equal(http.MethodGet, req.Method())
+	assert.Equal("/", req.Path())
+	assert.Equal(http.StatusOK, filtertest.Response(ctx).StatusCode())
+	assert.Empty(filtertest.ResponseBody(ctx))
+
+	stdr, _ := http.NewRequest(http.MethodPost, "http://example.com/abc", strings.NewReader("body"))
+	ctx = filtertest.NewHTTPContext(stdr)
+	req = filtertest.Request(ctx)
+	assert.Equal("/abc", req.Path())
+	assert.Equal("body", string(req.RawPayload()))
+}
+
+func ExampleNewHTTPContext_certExtractor() {
+	ce, err := filtertest.NewFilter(`
+kind: CertExtractor
+name: cn-extractor
+certIndex: 0
+target: subject
+field: CommonName
+headerKey: X-Client-CN
+`)
+	if err != nil {
+		panic(err)
+	}
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}}
+	ctx := filtertest.NewHTTPContext(nil)
+	filtertest.Request(ctx).SetConnectionState(&tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+	})
+
+	ce.Handle(ctx)
+	fmt.Println(filtertest.Request(ctx).HTTPHeader().Get("X-Client-CN"))
+	// Output: client
+}
+
+func ExampleNewHTTPContext_fallback() {
+	fb, err := filtertest.NewFilter(`
+kind: Fallback
+name: fallback
+mockCode: 503
+mockBody: service unavailable
+`)
+	if err != nil {
+		panic(err)
+	}
+
+	ctx := filtertest.NewHTTPContext(nil)
+	result := fb.Handle(ctx)
+	fmt.Println(result, filtertest.Response(ctx).StatusCode(), filtertest.ResponseBody(ctx))
+	// Output: fallback 503 service unavailable
+}
+
+func ExampleTags() {
+	ce, err := filtertest.NewFilter(`
+kind: CertExtractor
+name: cn-extractor
+certIndex: 0
+target: subject
+field: CommonName
+allow:
+- exact: trusted
+`)
+	if err != nil {
+		panic(err)
+	}
+
+	// the request has no client certificate, so it is forbidden.
+	ctx := filtertest.NewHTTPContext(nil)
+	result := ce.Handle(ctx)
+	fmt.Println(result, filtertest.Response(ctx).StatusCode(), filtertest.Tags(ctx))
+	// Output: forbidden 403 [certExtractor: forbidden]
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) 2017, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filtertest
+
+import (
+	"github.com/megaease/easegress/v2/pkg/cluster"
+	"github.com/megaease/easegress/v2/pkg/cluster/clustertest"
+	"github.com/megaease/easegress/v2/pkg/option"
+	"github.com/megaease/easegress/v2/pkg/supervisor"
+)
+
+// NewSupervisor creates a supervisor for filters depending on the cluster or
+// system controllers. The cluster is cls, or a clustertest.MockedCluster if
+// cls is nil. The system controllers are stubbed by controllers, whose keys
+// are the kinds of the controllers.
+func NewSupervisor(cls cluster.Cluster, controllers map[string]supervisor.Object) *supervisor.Supervisor {
+	if cls == nil {
+		cls = clustertest.NewMockedCluster()
+	}
+
+	super := supervisor.NewMock(option.New(), cls, nil, nil, false, nil, nil)
+	for kind, controller := range controllers {
+		super.MockSystemController(kind, controller)
+	}
+	return super
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2017, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filtertest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadCertChain loads the certificates from a PEM file, the first one is the
+// leaf and the following ones are the intermediates and the root, which is
+// the same order as a certificate chain sent by a TLS client.
+func LoadCertChain(file string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		chain = append(chain, cert)
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%s: no certificate found", file)
+	}
+	return chain, nil
+}
+
+// NewConnectionState creates a TLS connection state whose peer certificates
+// are chain. The chain is verified against the self-signed certificates in
+// it, and VerifiedChains is set if the verification succeeds.
+// The result is usually set to a request by SetConnectionState.
+func NewConnectionState(chain []*x509.Certificate) *tls.ConnectionState {
+	cs := &tls.ConnectionState{
+		HandshakeComplete: true,
+		PeerCertificates:  chain,
+	}
+	if len(chain) == 0 {
+		return cs
+	}
+
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		if bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+			roots.AddCert(cert)
+		} else {
+			intermediates.AddCert(cert)
+		}
+	}
+
+	verifiedChains, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err == nil {
+		cs.VerifiedChains = verifiedChains
+	}
+	return cs
+}
+
+// LoadConnectionStates loads every .pem and .crt file in dir as a certificate
+// chain by LoadCertChain, and returns the TLS connection states created by
+// NewConnectionState, the keys are the file names.
+func LoadConnectionStates(dir string) (map[string]*tls.ConnectionState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	states := map[string]*tls.ConnectionState{}
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		if entry.IsDir() || (ext != ".pem" && ext != ".crt") {
+			continue
+		}
+
+		chain, err := LoadCertChain(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		states[name] = NewConnectionState(chain)
+	}
+	return states, nil
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2017, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filtertest_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/megaease/easegress/v2/pkg/filters/filtertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func createCert(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return cert, key
+}
+
+func writePEM(t *testing.T, file string, certs ...*x509.Certificate) {
+	var data []byte
+	for _, cert := range certs {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	assert.Nil(t, os.WriteFile(file, data, 0o600))
+}
+
+func TestLoadConnectionStates(t *testing.T) {
+	assert := assert.New(t)
+
+	root, rootKey := createCert(t, "root", nil, nil)
+	client, _ := createCert(t, "client", root, rootKey)
+	other, _ := createCert(t, "other", nil, nil)
+
+	dir := t.TempDir()
+	writePEM(t, filepath.Join(dir, "client.pem"), client, root)
+	writePEM(t, filepath.Join(dir, "other.crt"), other)
+	assert.Nil(os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0o600))
+
+	states, err := filtertest.LoadConnectionStates(dir)
+	assert.Nil(err)
+	assert.Len(states, 2)
+	assert.Len(states["client.pem"].PeerCertificates, 2)
+	assert.Len(states["client.pem"].VerifiedChains, 1)
+	assert.Empty(states["other.crt"].VerifiedChains)
+
+	ce, err := filtertest.NewFilter(`
+kind: CertExtractor
+name: cn-extractor
+certIndex: 0
+target: subject
+field: CommonName
+headerKey: X-Client-CN
+verifiedHeaderKey: X-Client-Verified
+`)
+	assert.Nil(err)
+
+	for file, expected := range map[string][2]string{
+		"client.pem": {"client", "true"},
+		"other.crt":  {"other", "false"},
+	} {
+		ctx := filtertest.NewHTTPContext(nil)
+		filtertest.Request(ctx).SetConnectionState(states[file])
+		ce.Handle(ctx)
+		header := filtertest.Request(ctx).HTTPHeader()
+		assert.Equal(expected[0], header.Get("X-Client-CN"), file)
+		assert.Equal(expected[1], header.Get("X-Client-Verified"), file)
+	}
+
+	// invalid files
+	assert.Nil(os.WriteFile(filepath.Join(dir, "empty.pem"), nil, 0o600))
+	_, err = filtertest.LoadConnectionStates(dir)
+	assert.NotNil(err)
+
+	_, err = filtertest.LoadConnectionStates(filepath.Join(dir, "not-exist"))
+	assert.NotNil(err)
+
+	_, err = filtertest.LoadCertChain(filepath.Join(dir, "not-exist.pem"))
+	assert.NotNil(err)
+}
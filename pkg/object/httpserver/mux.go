@@ -274,6 +274,10 @@ func (mi *muxInstance) serveHTTP(stdw http.ResponseWriter, stdr *http.Request) {
 	// get topN here, as the path could be modified later.
 	topN := mi.topN.Stat(req.Path())
 
+	// check the header limits before any modification, so that headers
+	// added by the server, e.g. X-Forwarded-For, are not counted.
+	headerLimitErr := req.CheckHeaderLimits(mi.spec.ClientMaxHeaderCount, mi.spec.ClientMaxHeaderBytes)
+
 	routeCtx := routers.NewContext(req)
 	route := mi.search(routeCtx)
 	ctx.SetRoute(route.route)
@@ -331,6 +335,12 @@ func (mi *muxInstance) serveHTTP(stdw http.ResponseWriter, stdr *http.Request) {
 		})
 	}()
 
+	if headerLimitErr != nil {
+		logger.Errorf("%s: %s, you may need to increase 'clientMaxHeaderCount' or 'clientMaxHeaderBytes'", mi.superSpec.Name(), headerLimitErr.Error())
+		buildFailureResponse(ctx, http.StatusRequestHeaderFieldsTooLarge)
+		return
+	}
+
 	if route.code != 0 {
 		logger.Errorf("%s: status code of result route for [%s %s]: %d", mi.superSpec.Name(), req.Method(), req.RequestURI, route.code)
 		buildFailureResponse(ctx, route.code)
@@ -225,6 +225,25 @@ rules:
 	stdw = httptest.NewRecorder()
 	m.ServeHTTP(stdw, stdr)
 	assert.Equal(http.StatusBadRequest, stdw.Code)
+
+	// too many headers
+	superSpec, err = supervisor.NewSpec(yamlConfig + "clientMaxHeaderCount: 1\n")
+	assert.NoError(err)
+	assert.NotPanics(func() { m.reload(superSpec, mm) })
+	stdr, _ = http.NewRequest(http.MethodGet, "http://www.megaease.com/abc", http.NoBody)
+	stdr.Header.Add("X-A", "1")
+	stdr.Header.Add("X-B", "2")
+	stdw = httptest.NewRecorder()
+	m.ServeHTTP(stdw, stdr)
+	assert.Equal(http.StatusRequestHeaderFieldsTooLarge, stdw.Code)
+
+	// X-Forwarded-For added by the server is not counted
+	stdr, _ = http.NewRequest(http.MethodGet, "http://www.megaease.com/abc", http.NoBody)
+	stdr.Header.Add("X-A", "1")
+	stdw = httptest.NewRecorder()
+	m.ServeHTTP(stdw, stdr)
+	assert.NotEqual(http.StatusRequestHeaderFieldsTooLarge, stdw.Code)
+	assert.Contains(stdr.Header, "X-Forwarded-For")
 }
 
 func TestMuxInstanceSearch(t *testing.T) {
@@ -64,6 +64,11 @@ type (
 		GlobalFilter string `json:"globalFilter,omitempty"`
 
 		AccessLogFormat string `json:"accessLogFormat,omitempty"`
+
+		// ClientMaxHeaderCount and ClientMaxHeaderBytes limit the number of
+		// header lines and the total size of the headers of a request.
+		ClientMaxHeaderCount int   `json:"clientMaxHeaderCount,omitempty" jsonschema:"minimum=0"`
+		ClientMaxHeaderBytes int64 `json:"clientMaxHeaderBytes,omitempty" jsonschema:"minimum=0"`
 	}
 )
 
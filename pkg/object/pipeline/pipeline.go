@@ -26,6 +26,7 @@ import (
 	"github.com/megaease/easegress/v2/pkg/api"
 	"github.com/megaease/easegress/v2/pkg/context"
 	"github.com/megaease/easegress/v2/pkg/filters"
+	"github.com/megaease/easegress/v2/pkg/logger"
 	"github.com/megaease/easegress/v2/pkg/resilience"
 	"github.com/megaease/easegress/v2/pkg/supervisor"
 	"github.com/megaease/easegress/v2/pkg/util/easemonitor"
@@ -137,6 +138,44 @@ func (s *Spec) ValidateJumpIf(specs map[string]filters.Spec) {
 	}
 }
 
+// checkFilterOrder checks whether the order of the filters contradicts the
+// Before hints of the filter kinds, and returns a warning message for each
+// contradiction. The order is the flow if defined, otherwise the filters.
+func (s *Spec) checkFilterOrder(specs map[string]filters.Spec) []string {
+	var names []string
+	if len(s.Flow) == 0 {
+		for _, f := range s.Filters {
+			name, _ := f["name"].(string)
+			names = append(names, name)
+		}
+	} else {
+		for i := range s.Flow {
+			names = append(names, s.Flow[i].FilterName)
+		}
+	}
+
+	var warnings []string
+	for i := range names {
+		former := specs[names[i]]
+		if former == nil {
+			continue
+		}
+		for j := i + 1; j < len(names); j++ {
+			latter := specs[names[j]]
+			if latter == nil {
+				continue
+			}
+			kind := filters.GetKind(latter.Kind())
+			if kind != nil && kind.ShouldRunBefore(former.Kind()) {
+				msgFmt := "filter %s(%s) should be placed before filter %s(%s)"
+				warnings = append(warnings, fmt.Sprintf(msgFmt, latter.Name(), latter.Kind(), former.Name(), former.Kind()))
+			}
+		}
+	}
+
+	return warnings
+}
+
 // Validate validates Spec.
 func (s *Spec) Validate() (err error) {
 	errPrefix := "filters"
@@ -170,6 +209,9 @@ func (s *Spec) Validate() (err error) {
 	// 2: validate flow
 	errPrefix = "flow"
 	s.ValidateJumpIf(specs)
+	for _, w := range s.checkFilterOrder(specs) {
+		logger.Warnf("flow: %s", w)
+	}
 
 	// 3: validate resilience
 	for _, r := range s.Resilience {
@@ -189,6 +189,59 @@ filters:
 	})
 }
 
+func TestCheckFilterOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	cleanup()
+	filters.Register(MockFilterKind("auth-filter", nil))
+	identity := MockFilterKind("identity-filter", nil)
+	identity.Before = []string{"auth-filter"}
+	filters.Register(identity)
+
+	newSpec := func(yamlConfig string) (*Spec, map[string]filters.Spec) {
+		superSpec, err := supervisor.NewSpec(yamlConfig)
+		assert.Nil(err)
+		spec := superSpec.ObjectSpec().(*Spec)
+		specs := map[string]filters.Spec{}
+		for _, f := range spec.Filters {
+			fs, err := filters.NewSpec(nil, "", f)
+			assert.Nil(err)
+			specs[fs.Name()] = fs
+		}
+		return spec, specs
+	}
+
+	spec, specs := newSpec(`name: pipeline
+kind: Pipeline
+filters:
+- name: identity
+  kind: identity-filter
+- name: auth
+  kind: auth-filter`)
+	assert.Empty(spec.checkFilterOrder(specs))
+
+	spec, specs = newSpec(`name: pipeline
+kind: Pipeline
+filters:
+- name: auth
+  kind: auth-filter
+- name: identity
+  kind: identity-filter`)
+	assert.Len(spec.checkFilterOrder(specs), 1)
+
+	spec, specs = newSpec(`name: pipeline
+kind: Pipeline
+flow:
+- filter: identity
+- filter: auth
+filters:
+- name: auth
+  kind: auth-filter
+- name: identity
+  kind: identity-filter`)
+	assert.Empty(spec.checkFilterOrder(specs))
+}
+
 func TestRegistry(t *testing.T) {
 	cleanup()
 	t.Run("duplicate filter name", func(t *testing.T) {
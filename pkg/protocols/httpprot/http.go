@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/megaease/easegress/v2/pkg/protocols"
@@ -31,6 +32,10 @@ import (
 // DefaultMaxPayloadSize is the default max allowed payload size.
 const DefaultMaxPayloadSize = 4 * 1024 * 1024
 
+// ErrBodyTooLarge is returned by the reader created by LimitedReader when
+// the body is larger than the limit.
+var ErrBodyTooLarge = fmt.Errorf("body too large")
+
 func init() {
 	protocols.Register("http", &Protocol{})
 }
@@ -107,6 +112,43 @@ func (p *Protocol) CreateResponse(resp interface{}) (protocols.Response, error)
 	return NewResponse(r)
 }
 
+// limitedReader reads at most n bytes from r, and returns ErrBodyTooLarge
+// if there are more data.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+// LimitedReader returns a reader that reads from r, and returns
+// ErrBodyTooLarge once more than max bytes are read. Different from
+// io.LimitReader, which stops silently at the limit, the returned
+// reader reports the data is truncated. Reading exactly max bytes is
+// not an error.
+func LimitedReader(r io.Reader, max int64) io.Reader {
+	return &limitedReader{r: r, n: max}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrBodyTooLarge
+	}
+
+	// read one more byte to check whether the data exceeds the limit.
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+
+	n, err := l.r.Read(p)
+	if int64(n) <= l.n {
+		l.n -= int64(n)
+		return n, err
+	}
+
+	n = int(l.n)
+	l.n = -1
+	return n, ErrBodyTooLarge
+}
+
 func parseJSONBody(body []byte) (interface{}, error) {
 	var v interface{}
 	d := json.NewDecoder(bytes.NewReader(body))
@@ -18,10 +18,12 @@
 package httpprot
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -199,3 +201,36 @@ name: 123
 		assert.Nil(err)
 	}
 }
+
+func TestLimitedReader(t *testing.T) {
+	assert := assert.New(t)
+
+	data, err := io.ReadAll(LimitedReader(strings.NewReader("0123456789"), 10))
+	assert.Nil(err)
+	assert.Equal("0123456789", string(data))
+
+	data, err = io.ReadAll(LimitedReader(strings.NewReader("0123456789"), 20))
+	assert.Nil(err)
+	assert.Equal("0123456789", string(data))
+
+	data, err = io.ReadAll(LimitedReader(strings.NewReader("0123456789"), 9))
+	assert.Equal(ErrBodyTooLarge, err)
+	assert.Equal("012345678", string(data))
+
+	data, err = io.ReadAll(LimitedReader(strings.NewReader(""), 0))
+	assert.Nil(err)
+	assert.Empty(data)
+
+	data, err = io.ReadAll(LimitedReader(strings.NewReader("0"), 0))
+	assert.Equal(ErrBodyTooLarge, err)
+	assert.Empty(data)
+
+	// read byte by byte, the limit is still exact.
+	r := LimitedReader(iotest.OneByteReader(strings.NewReader("0123")), 3)
+	data, err = io.ReadAll(r)
+	assert.Equal(ErrBodyTooLarge, err)
+	assert.Equal("012", string(data))
+	n, err := r.Read(make([]byte, 10))
+	assert.Zero(n)
+	assert.Equal(ErrBodyTooLarge, err)
+}
@@ -29,13 +29,18 @@ type (
 		h http.Header
 	}
 
-	// AdaptSpec describes rules for adapting.
+	// AdaptSpec describes rules for adapting, the rules are applied in the
+	// order of Del, Set, Add and Append.
 	AdaptSpec struct {
 		Del []string `json:"del,omitempty" jsonschema:"uniqueItems=true"`
 
 		// NOTE: Set and Add allow empty value.
 		Set map[string]string `json:"set,omitempty"`
 		Add map[string]string `json:"add,omitempty"`
+		// Append appends the value to the comma-separated list of the last
+		// existing value of the header, e.g. a Via entry, unlike Add, it
+		// doesn't create a new header line if the header exists.
+		Append map[string]string `json:"append,omitempty"`
 	}
 )
 
@@ -93,6 +98,21 @@ func (h *HTTPHeader) Set(key, value string) {
 	h.h.Set(key, value)
 }
 
+// Append appends value to the comma-separated list of the last value of key,
+// or sets the key value pair if key does not exist.
+func (h *HTTPHeader) Append(key, value string) {
+	key = textproto.CanonicalMIMEHeaderKey(key)
+	values := h.h[key]
+	switch {
+	case len(values) == 0:
+		h.h[key] = []string{value}
+	case values[len(values)-1] == "":
+		values[len(values)-1] = value
+	default:
+		values[len(values)-1] += ", " + value
+	}
+}
+
 // Del deletes the key value pair by the key.
 func (h *HTTPHeader) Del(key string) {
 	h.h.Del(key)
@@ -169,4 +189,8 @@ func (h *HTTPHeader) Adapt(as *AdaptSpec) {
 	for key, value := range as.Add {
 		h.Add(key, value)
 	}
+
+	for key, value := range as.Append {
+		h.Append(key, value)
+	}
 }
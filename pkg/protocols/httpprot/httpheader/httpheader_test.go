@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2017, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpheader
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppend(t *testing.T) {
+	assert := assert.New(t)
+
+	h := New(http.Header{})
+	h.Append("via", "1.1 a")
+	assert.Equal([]string{"1.1 a"}, h.GetAll("Via"))
+
+	h.Append("Via", "1.1 b")
+	assert.Equal([]string{"1.1 a, 1.1 b"}, h.GetAll("Via"))
+
+	h.Std()["X-Multi"] = []string{"v1", "v2"}
+	h.Append("X-Multi", "v3")
+	assert.Equal([]string{"v1", "v2, v3"}, h.GetAll("X-Multi"))
+
+	h.Std()["X-Empty"] = []string{"v1", ""}
+	h.Append("X-Empty", "v2")
+	assert.Equal([]string{"v1", "v2"}, h.GetAll("X-Empty"))
+}
+
+func TestAdapt(t *testing.T) {
+	assert := assert.New(t)
+
+	h := New(http.Header{
+		"Via":     {"1.0 a", "1.1 b"},
+		"X-Del":   {"v"},
+		"X-Set":   {"v1", "v2"},
+		"X-Added": {"v1"},
+	})
+	h.Adapt(&AdaptSpec{
+		Del:    []string{"X-Del"},
+		Set:    map[string]string{"X-Set": "v", "X-Appended": "v1"},
+		Add:    map[string]string{"X-Added": "v2"},
+		Append: map[string]string{"Via": "1.1 eg", "X-Appended": "v2"},
+	})
+
+	assert.Empty(h.GetAll("X-Del"))
+	assert.Equal([]string{"v"}, h.GetAll("X-Set"))
+	assert.Equal([]string{"v1", "v2"}, h.GetAll("X-Added"))
+	assert.Equal([]string{"1.0 a", "1.1 b, 1.1 eg"}, h.GetAll("Via"))
+	// Append is applied after Set.
+	assert.Equal([]string{"v1, v2"}, h.GetAll("X-Appended"))
+}
@@ -20,11 +20,13 @@ package httpprot
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/megaease/easegress/v2/pkg/protocols"
@@ -45,12 +47,24 @@ type Request struct {
 	stream  *readers.ByteCountReader
 	payload []byte
 	realIP  string
+
+	// query caches the parsed query values of rawQuery.
+	query    url.Values
+	rawQuery string
+
+	// connState overrides the TLS connection state of the underlying
+	// request if it is not nil.
+	connState *tls.ConnectionState
 }
 
 var (
 	// ErrRequestEntityTooLarge means the request entity is too large.
 	ErrRequestEntityTooLarge = fmt.Errorf("request entity too large")
 
+	// ErrRequestHeaderFieldsTooLarge means the request has too many headers
+	// or the headers are too large.
+	ErrRequestHeaderFieldsTooLarge = fmt.Errorf("request header fields too large")
+
 	methods = map[string]struct{}{
 		http.MethodGet:     {},
 		http.MethodHead:    {},
@@ -63,6 +77,15 @@ var (
 		http.MethodTrace:   {},
 	}
 
+	// tlsVersionNames are the names of the TLS versions, tls.VersionName
+	// is not used as it requires Go 1.21.
+	tlsVersionNames = map[uint16]string{
+		tls.VersionTLS10: "TLS 1.0",
+		tls.VersionTLS11: "TLS 1.1",
+		tls.VersionTLS12: "TLS 1.2",
+		tls.VersionTLS13: "TLS 1.3",
+	}
+
 	_ protocols.Request = (*Request)(nil)
 )
 
@@ -143,6 +166,33 @@ func (r *Request) FetchPayload(maxPayloadSize int64) error {
 	return err
 }
 
+// CheckHeaderLimits checks the headers of the request against the limits,
+// it returns ErrRequestHeaderFieldsTooLarge if the number of header lines
+// exceeds maxCount, or the total size of the header lines exceeds maxBytes.
+// A zero or negative limit means no limit.
+func (r *Request) CheckHeaderLimits(maxCount int, maxBytes int64) error {
+	if maxCount <= 0 && maxBytes <= 0 {
+		return nil
+	}
+
+	count, size := 0, int64(0)
+	for key, values := range r.HTTPHeader() {
+		for _, value := range values {
+			count++
+			// key + ": " + value + "\r\n"
+			size += int64(len(key) + len(value) + 4)
+		}
+	}
+
+	if maxCount > 0 && count > maxCount {
+		return ErrRequestHeaderFieldsTooLarge
+	}
+	if maxBytes > 0 && size > maxBytes {
+		return ErrRequestHeaderFieldsTooLarge
+	}
+	return nil
+}
+
 // SetPayload set the payload of the request to payload. The payload
 // could be a string, a byte slice, or an io.Reader, and if it is an
 // io.Reader, it will be treated as a stream, if this is not desired,
@@ -172,6 +222,26 @@ func (r *Request) SetPayload(payload interface{}) {
 	}
 }
 
+// SetBody reads all data from body and sets it as the payload of the request,
+// so that the payload can be read again by the following filters, e.g. after
+// a filter consumed a stream payload. If resetContentLength is true, the
+// Content-Length of the request is updated to the size of the payload.
+//
+// Like SetPayload, it never replaces the body of the underlying http.Request.
+func (r *Request) SetBody(body io.Reader, resetContentLength bool) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	r.SetPayload(data)
+	if resetContentLength {
+		r.Std().ContentLength = int64(len(data))
+		r.HTTPHeader().Set("Content-Length", strconv.Itoa(len(data)))
+	}
+	return nil
+}
+
 // GetPayload returns a payload reader. For non-stream payload, the
 // returned reader is always a new one, which contains the full data.
 // For stream payload, the function always returns the same reader.
@@ -305,11 +375,79 @@ func (r *Request) URL() *url.URL {
 	return r.Std().URL
 }
 
+// QueryValues returns the parsed query values of the request. The values are
+// cached and parsed again only if the raw query is changed, so the caller
+// should not modify the return value.
+func (r *Request) QueryValues() url.Values {
+	rawQuery := r.Std().URL.RawQuery
+	if r.query == nil || r.rawQuery != rawQuery {
+		r.query, _ = url.ParseQuery(rawQuery)
+		r.rawQuery = rawQuery
+	}
+	return r.query
+}
+
+// QueryParam returns the first value of the named query parameter, or an
+// empty string if there's no such parameter.
+func (r *Request) QueryParam(name string) string {
+	return r.QueryValues().Get(name)
+}
+
 // Proto returns proto of the request.
 func (r *Request) Proto() string {
 	return r.Std().Proto
 }
 
+// ProtoMajor returns the major version of the protocol of the request.
+func (r *Request) ProtoMajor() int {
+	return r.Std().ProtoMajor
+}
+
+// IsHTTP2 returns whether the request arrived over HTTP/2.
+func (r *Request) IsHTTP2() bool {
+	return r.ProtoMajor() == 2
+}
+
+// ConnectionState returns the TLS connection state of the request, which is
+// the one set by SetConnectionState, or the one of the underlying request.
+// It returns nil if the connection isn't TLS.
+func (r *Request) ConnectionState() *tls.ConnectionState {
+	if r.connState != nil {
+		return r.connState
+	}
+	return r.Std().TLS
+}
+
+// SetConnectionState overrides the TLS connection state of the request, it
+// is mainly for testing filters depending on TLS without real connections.
+func (r *Request) SetConnectionState(cs *tls.ConnectionState) {
+	r.connState = cs
+}
+
+// TLSVersion returns the name of the TLS version of the connection, e.g.
+// "TLS 1.3", or an empty string if the connection isn't TLS.
+func (r *Request) TLSVersion() string {
+	cs := r.ConnectionState()
+	if cs == nil {
+		return ""
+	}
+	version := cs.Version
+	if name, ok := tlsVersionNames[version]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04X", version)
+}
+
+// CipherSuite returns the name of the cipher suite of the connection, e.g.
+// "TLS_AES_128_GCM_SHA256", or an empty string if the connection isn't TLS.
+func (r *Request) CipherSuite() string {
+	cs := r.ConnectionState()
+	if cs == nil {
+		return ""
+	}
+	return tls.CipherSuiteName(cs.CipherSuite)
+}
+
 // Method returns method of the request.
 func (r *Request) Method() string {
 	return r.Std().Method
@@ -18,13 +18,17 @@
 package httpprot
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/megaease/easegress/v2/pkg/util/readers"
 	"github.com/stretchr/testify/assert"
@@ -209,6 +213,24 @@ func TestRequest2(t *testing.T) {
 	}
 }
 
+func TestCheckHeaderLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	req := getRequest(t, http.MethodGet, "http://127.0.0.1:8888", http.NoBody)
+	req.HTTPHeader().Add("X-A", "1")
+	req.HTTPHeader().Add("X-A", "2")
+	req.HTTPHeader().Add("X-B", "3")
+
+	assert.Nil(req.CheckHeaderLimits(0, 0))
+	assert.Nil(req.CheckHeaderLimits(3, 0))
+	assert.Equal(ErrRequestHeaderFieldsTooLarge, req.CheckHeaderLimits(2, 0))
+
+	// each line is len("X-A: 1\r\n") = 8 bytes.
+	assert.Nil(req.CheckHeaderLimits(0, 24))
+	assert.Equal(ErrRequestHeaderFieldsTooLarge, req.CheckHeaderLimits(0, 23))
+	assert.Equal(ErrRequestHeaderFieldsTooLarge, req.CheckHeaderLimits(10, 23))
+}
+
 func TestBuilderRequest(t *testing.T) {
 	assert := assert.New(t)
 
@@ -265,3 +287,125 @@ kind: Test
 		assert.Equal("Test", yamlMap["kind"])
 	}
 }
+
+func TestQueryValues(t *testing.T) {
+	assert := assert.New(t)
+
+	stdr, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/?a=1&a=2&b=3", nil)
+	req, _ := NewRequest(stdr)
+	assert.Equal([]string{"1", "2"}, req.QueryValues()["a"])
+	assert.Equal("1", req.QueryParam("a"))
+	assert.Equal("3", req.QueryParam("b"))
+	assert.Empty(req.QueryParam("c"))
+
+	req.URL().RawQuery = "c=4"
+	assert.Empty(req.QueryParam("a"))
+	assert.Equal("4", req.QueryParam("c"))
+
+	req.URL().RawQuery = ""
+	assert.Empty(req.QueryValues())
+}
+
+func BenchmarkQueryValues(b *testing.B) {
+	stdr, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/?a=1&b=2&c=3&d=4&e=5", nil)
+	req, _ := NewRequest(stdr)
+
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			req.QueryParam("e")
+		}
+	})
+
+	b.Run("std", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			req.URL().Query().Get("e")
+		}
+	})
+}
+
+func TestRequestProto(t *testing.T) {
+	assert := assert.New(t)
+
+	stdr, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/", nil)
+	req, _ := NewRequest(stdr)
+	assert.Equal(1, req.ProtoMajor())
+	assert.False(req.IsHTTP2())
+
+	var isHTTP2 bool
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, stdr *http.Request) {
+		req, _ := NewRequest(stdr)
+		isHTTP2 = req.IsHTTP2()
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	assert.Nil(err)
+	resp.Body.Close()
+	assert.Equal(2, resp.ProtoMajor)
+	assert.True(isHTTP2)
+}
+
+func TestRequestSetBody(t *testing.T) {
+	assert := assert.New(t)
+
+	stdr, _ := http.NewRequest(http.MethodPost, "http://127.0.0.1/", strings.NewReader("body"))
+	req, _ := NewRequest(stdr)
+	assert.Nil(req.FetchPayload(-1))
+
+	// consume the stream payload and restore it.
+	data, _ := io.ReadAll(req.GetPayload())
+	assert.Equal("body", string(data))
+	assert.Nil(req.SetBody(bytes.NewReader(data), false))
+	assert.False(req.IsStream())
+	for i := 0; i < 2; i++ {
+		data, _ = io.ReadAll(req.GetPayload())
+		assert.Equal("body", string(data))
+	}
+	assert.Equal(int64(4), stdr.ContentLength)
+
+	assert.Nil(req.SetBody(strings.NewReader("new body"), true))
+	assert.Equal("new body", string(req.RawPayload()))
+	assert.Equal(int64(8), stdr.ContentLength)
+	assert.Equal("8", req.HTTPHeader().Get("Content-Length"))
+
+	err := req.SetBody(iotest.ErrReader(fmt.Errorf("read error")), true)
+	assert.NotNil(err)
+	assert.Equal("new body", string(req.RawPayload()))
+}
+
+func TestRequestTLS(t *testing.T) {
+	assert := assert.New(t)
+
+	stdr, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/", nil)
+	req, _ := NewRequest(stdr)
+	assert.Empty(req.TLSVersion())
+	assert.Empty(req.CipherSuite())
+
+	stdr.TLS = &tls.ConnectionState{
+		Version:     tls.VersionTLS13,
+		CipherSuite: tls.TLS_AES_128_GCM_SHA256,
+	}
+	assert.Equal("TLS 1.3", req.TLSVersion())
+	assert.Equal("TLS_AES_128_GCM_SHA256", req.CipherSuite())
+
+	stdr.TLS = &tls.ConnectionState{
+		Version:     tls.VersionTLS12,
+		CipherSuite: tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	}
+	assert.Equal("TLS 1.2", req.TLSVersion())
+	assert.Equal("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", req.CipherSuite())
+
+	stdr.TLS = &tls.ConnectionState{Version: 0x0305}
+	assert.Equal("0x0305", req.TLSVersion())
+
+	// the overridden connection state
+	assert.Equal(stdr.TLS, req.ConnectionState())
+	cs := &tls.ConnectionState{Version: tls.VersionTLS11}
+	req.SetConnectionState(cs)
+	assert.Same(cs, req.ConnectionState())
+	assert.Equal("TLS 1.1", req.TLSVersion())
+	req.SetConnectionState(nil)
+	assert.Equal("0x0305", req.TLSVersion())
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) 2017, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpprot
+
+import (
+	"github.com/megaease/easegress/v2/pkg/util/stringtool"
+)
+
+// RequestMatcher matches HTTP requests by method, path and headers, it is
+// designed to be embedded in the spec of filters.
+//
+// A request matches only if it matches all of the specified conditions:
+// its method is one of Methods, its path matches Path, and its headers
+// match Headers. For headers, matching one of them is enough, unless
+// MatchAllHeaders is true. A missing header is treated as an empty value.
+// Conditions not specified always match, so an empty matcher matches all
+// requests.
+type RequestMatcher struct {
+	Methods         []string                             `json:"methods,omitempty" jsonschema:"uniqueItems=true,format=httpmethod-array"`
+	Path            *stringtool.StringMatcher            `json:"path,omitempty"`
+	Headers         map[string]*stringtool.StringMatcher `json:"headers,omitempty"`
+	MatchAllHeaders bool                                 `json:"matchAllHeaders,omitempty"`
+}
+
+// Validate validates the RequestMatcher.
+func (rm *RequestMatcher) Validate() error {
+	if rm.Path != nil {
+		if err := rm.Path.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, h := range rm.Headers {
+		if err := h.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Init initializes the RequestMatcher, it must be called before Match.
+func (rm *RequestMatcher) Init() {
+	if rm.Path != nil {
+		rm.Path.Init()
+	}
+
+	for _, h := range rm.Headers {
+		h.Init()
+	}
+}
+
+// Match returns whether req matches the RequestMatcher.
+func (rm *RequestMatcher) Match(req *Request) bool {
+	if len(rm.Methods) > 0 && !stringtool.StrInSlice(req.Method(), rm.Methods) {
+		return false
+	}
+
+	if rm.Path != nil && !rm.Path.Match(req.Path()) {
+		return false
+	}
+
+	if len(rm.Headers) == 0 {
+		return true
+	}
+
+	h := req.HTTPHeader()
+	for key, rule := range rm.Headers {
+		values := h.Values(key)
+		if len(values) == 0 {
+			values = []string{""}
+		}
+
+		matched := rule.MatchAny(values)
+		if matched && !rm.MatchAllHeaders {
+			return true
+		}
+		if !matched && rm.MatchAllHeaders {
+			return false
+		}
+	}
+
+	return rm.MatchAllHeaders
+}
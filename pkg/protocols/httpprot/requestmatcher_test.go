@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2017, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpprot
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/megaease/easegress/v2/pkg/util/codectool"
+	"github.com/megaease/easegress/v2/pkg/util/stringtool"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMatcher(t *testing.T, yamlConfig string) *RequestMatcher {
+	rm := &RequestMatcher{}
+	codectool.MustUnmarshal([]byte(yamlConfig), rm)
+	assert.Nil(t, rm.Validate())
+	rm.Init()
+	return rm
+}
+
+func TestRequestMatcher(t *testing.T) {
+	assert := assert.New(t)
+
+	newReq := func(method, path string, headers map[string]string) *Request {
+		req := getRequest(t, method, "http://127.0.0.1:8888"+path, http.NoBody)
+		for k, v := range headers {
+			req.HTTPHeader().Set(k, v)
+		}
+		return req
+	}
+
+	t.Run("empty matcher", func(t *testing.T) {
+		rm := newMatcher(t, "{}")
+		assert.True(rm.Match(newReq(http.MethodGet, "/", nil)))
+		assert.True(rm.Match(newReq(http.MethodPost, "/abc", map[string]string{"X-A": "a"})))
+	})
+
+	t.Run("methods", func(t *testing.T) {
+		rm := newMatcher(t, "methods: [GET, HEAD]")
+		assert.True(rm.Match(newReq(http.MethodGet, "/", nil)))
+		assert.True(rm.Match(newReq(http.MethodHead, "/", nil)))
+		assert.False(rm.Match(newReq(http.MethodPost, "/", nil)))
+	})
+
+	t.Run("path", func(t *testing.T) {
+		rm := newMatcher(t, "path: {prefix: /api}")
+		assert.True(rm.Match(newReq(http.MethodGet, "/api/users", nil)))
+		assert.False(rm.Match(newReq(http.MethodGet, "/web", nil)))
+
+		rm = newMatcher(t, `path: {regex: "^/users/[0-9]+$"}`)
+		assert.True(rm.Match(newReq(http.MethodGet, "/users/123", nil)))
+		assert.False(rm.Match(newReq(http.MethodGet, "/users/abc", nil)))
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		yamlConfig := `
+headers:
+  X-A: {exact: a}
+  X-B: {empty: true}
+`
+		rm := newMatcher(t, yamlConfig)
+		assert.True(rm.Match(newReq(http.MethodGet, "/", nil)), "missing X-B is empty")
+		assert.True(rm.Match(newReq(http.MethodGet, "/", map[string]string{"X-A": "a", "X-B": "b"})))
+		assert.False(rm.Match(newReq(http.MethodGet, "/", map[string]string{"X-A": "x", "X-B": "b"})))
+
+		rm = newMatcher(t, yamlConfig+"matchAllHeaders: true\n")
+		assert.False(rm.Match(newReq(http.MethodGet, "/", nil)))
+		assert.True(rm.Match(newReq(http.MethodGet, "/", map[string]string{"X-A": "a"})))
+		assert.False(rm.Match(newReq(http.MethodGet, "/", map[string]string{"X-A": "a", "X-B": "b"})))
+
+		req := newReq(http.MethodGet, "/", nil)
+		req.HTTPHeader().Add("X-A", "x")
+		req.HTTPHeader().Add("X-A", "a")
+		assert.True(rm.Match(req), "any value of a multi-valued header")
+	})
+
+	t.Run("all conditions", func(t *testing.T) {
+		rm := newMatcher(t, `
+methods: [POST]
+path: {exact: /login}
+headers:
+  Content-Type: {prefix: application/json}
+`)
+		headers := map[string]string{"Content-Type": "application/json; charset=utf-8"}
+		assert.True(rm.Match(newReq(http.MethodPost, "/login", headers)))
+		assert.False(rm.Match(newReq(http.MethodGet, "/login", headers)))
+		assert.False(rm.Match(newReq(http.MethodPost, "/logout", headers)))
+		assert.False(rm.Match(newReq(http.MethodPost, "/login", nil)))
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		rm := &RequestMatcher{Path: &stringtool.StringMatcher{}}
+		assert.NotNil(rm.Validate())
+
+		rm = &RequestMatcher{Headers: map[string]*stringtool.StringMatcher{"X-A": {}}}
+		assert.NotNil(rm.Validate())
+	})
+}
@@ -86,7 +86,7 @@ func (r *Response) FetchPayload(maxPayloadSize int64) error {
 	}
 
 	if maxPayloadSize < 0 {
-		r.SetPayload(r.Response.Body)
+		r.setPayload(r.Response.Body)
 		return nil
 	}
 
@@ -100,7 +100,7 @@ func (r *Response) FetchPayload(maxPayloadSize int64) error {
 		payload := make([]byte, stdr.ContentLength)
 		n, err := io.ReadFull(stdr.Body, payload)
 		payload = payload[:n]
-		r.SetPayload(payload)
+		r.setPayload(payload)
 		if err == io.EOF {
 			err = io.ErrUnexpectedEOF
 		}
@@ -108,12 +108,12 @@ func (r *Response) FetchPayload(maxPayloadSize int64) error {
 	}
 
 	if stdr.ContentLength == 0 {
-		r.SetPayload(nil)
+		r.setPayload(nil)
 		return nil
 	}
 
 	payload, err := io.ReadAll(io.LimitReader(stdr.Body, maxPayloadSize))
-	r.SetPayload(payload)
+	r.setPayload(payload)
 	if err != nil {
 		return err
 	}
@@ -136,7 +136,23 @@ func (r *Response) FetchPayload(maxPayloadSize int64) error {
 // io.Reader, it will be treated as a stream, if this is not desired,
 // please read the data to a byte slice, and set the byte slice as
 // the payload.
+//
+// The Content-Length header is updated to the size of a non-stream payload,
+// and removed for a stream payload, whose size is unknown.
 func (r *Response) SetPayload(payload interface{}) {
+	r.setPayload(payload)
+
+	if r.stream != nil {
+		r.ContentLength = -1
+		r.HTTPHeader().Del("Content-Length")
+		return
+	}
+	r.ContentLength = int64(len(r.payload))
+	r.HTTPHeader().Set("Content-Length", strconv.Itoa(len(r.payload)))
+}
+
+// setPayload sets the payload without touching the headers.
+func (r *Response) setPayload(payload interface{}) {
 	r.stream = nil
 	r.payload = nil
 
@@ -264,6 +280,39 @@ func (r *Response) SetStatusCode(code int) {
 	r.Std().StatusCode = code
 }
 
+// CopyTo copies the status code, headers and payload of r to dst. The
+// headers of dst are replaced by a copy of the headers of r, and if the
+// previous payload of dst is a stream, it is closed.
+//
+// A non-stream payload is shared by r and dst, because a payload is never
+// modified in place. A stream payload can only be read once, so it is moved
+// to dst, and r must not be closed before dst finishes reading it.
+func (r *Response) CopyTo(dst *Response) {
+	if r == dst {
+		return
+	}
+
+	dst.SetStatusCode(r.StatusCode())
+	header := r.HTTPHeader().Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	dst.Std().Header = header
+	dst.ContentLength = r.ContentLength
+
+	if dst.stream != nil {
+		dst.stream.Close()
+	}
+
+	if r.stream != nil {
+		dst.setPayload(r.stream)
+		r.stream = nil
+		return
+	}
+
+	dst.setPayload(r.payload)
+}
+
 // SetCookie adds a Set-Cookie header to the response's headers.
 func (r *Response) SetCookie(cookie *http.Cookie) {
 	if v := cookie.String(); v != "" {
@@ -194,6 +194,50 @@ func TestResponse2(t *testing.T) {
 	}
 }
 
+func TestResponseCopyTo(t *testing.T) {
+	assert := assert.New(t)
+
+	src, _ := NewResponse(nil)
+	src.SetStatusCode(http.StatusCreated)
+	src.HTTPHeader().Add("X-Multi", "1")
+	src.HTTPHeader().Add("X-Multi", "2")
+	src.HTTPHeader().Add("Set-Cookie", "a=1")
+	src.HTTPHeader().Add("Set-Cookie", "b=2")
+	body := strings.Repeat("0123456789", 1024*1024)
+	src.SetPayload(body)
+
+	dst, _ := NewResponse(nil)
+	dst.HTTPHeader().Set("X-Old", "old")
+	dst.SetPayload(readers.NewByteCountReader(strings.NewReader("old")))
+
+	src.CopyTo(dst)
+	assert.Equal(http.StatusCreated, dst.StatusCode())
+	assert.Equal([]string{"1", "2"}, dst.HTTPHeader().Values("X-Multi"))
+	assert.Equal([]string{"a=1", "b=2"}, dst.HTTPHeader().Values("Set-Cookie"))
+	assert.Empty(dst.HTTPHeader().Get("X-Old"))
+	assert.False(dst.IsStream())
+	data, err := io.ReadAll(dst.GetPayload())
+	assert.Nil(err)
+	assert.Equal(body, string(data))
+
+	// headers are copied, not shared.
+	dst.HTTPHeader().Add("X-Multi", "3")
+	assert.Equal([]string{"1", "2"}, src.HTTPHeader().Values("X-Multi"))
+
+	// stream payload is moved.
+	src.SetPayload(strings.NewReader(body))
+	src.CopyTo(dst)
+	assert.True(dst.IsStream())
+	assert.False(src.IsStream())
+	data, err = io.ReadAll(dst.GetPayload())
+	assert.Nil(err)
+	assert.Equal(len(body), len(data))
+	assert.Equal(int64(len(body)), dst.PayloadSize())
+
+	src.CopyTo(src)
+	assert.Equal(http.StatusCreated, src.StatusCode())
+}
+
 func TestBuilderResponse(t *testing.T) {
 	assert := assert.New(t)
 	{
@@ -236,3 +280,54 @@ func TestBuilderResponse(t *testing.T) {
 		assert.NotNil(builderResp)
 	}
 }
+
+func TestResponseSetPayloadContentLength(t *testing.T) {
+	assert := assert.New(t)
+
+	resp, _ := NewResponse(nil)
+	resp.SetPayload("hello")
+	assert.Equal("5", resp.Header().Get("Content-Length"))
+	assert.Equal(int64(5), resp.ContentLength)
+
+	resp.SetPayload(nil)
+	assert.Equal("0", resp.Header().Get("Content-Length"))
+	assert.Equal(int64(0), resp.ContentLength)
+
+	resp.SetPayload(strings.NewReader("stream"))
+	assert.Empty(resp.Header().Get("Content-Length"))
+	assert.Equal(int64(-1), resp.ContentLength)
+
+	// FetchPayload keeps the headers of the original response.
+	stdr := &http.Response{
+		Header: http.Header{},
+		Body:   io.NopCloser(strings.NewReader("chunked")),
+	}
+	stdr.ContentLength = -1
+	resp, _ = NewResponse(stdr)
+	assert.Nil(resp.FetchPayload(0))
+	assert.Empty(resp.Header().Get("Content-Length"))
+}
+
+func TestResponseCookies(t *testing.T) {
+	assert := assert.New(t)
+
+	resp, _ := NewResponse(nil)
+	resp.SetCookie(&http.Cookie{Name: "session", Value: "s1", Path: "/", HttpOnly: true})
+	resp.SetCookie(&http.Cookie{Name: "lang", Value: "en, zh", MaxAge: 60})
+	resp.SetCookie(&http.Cookie{})
+	assert.Len(resp.HTTPHeader().Values("Set-Cookie"), 2)
+
+	cookies := resp.Cookies()
+	assert.Len(cookies, 2)
+	assert.Equal("session", cookies[0].Name)
+	assert.Equal("s1", cookies[0].Value)
+	assert.True(cookies[0].HttpOnly)
+	assert.Equal("lang", cookies[1].Name)
+	assert.Equal("en, zh", cookies[1].Value)
+	assert.Equal(60, cookies[1].MaxAge)
+
+	// cookies are kept when copied to another response.
+	dst, _ := NewResponse(nil)
+	resp.CopyTo(dst)
+	assert.Len(dst.Cookies(), 2)
+}
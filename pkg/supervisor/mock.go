@@ -40,3 +40,9 @@ func NewMock(options *option.Options, cls cluster.Cluster, objectRegistry *Objec
 func NewDefaultMock() *Supervisor {
 	return &Supervisor{}
 }
+
+// MockSystemController sets instance as the system controller of kind, for
+// testing purpose.
+func (s *Supervisor) MockSystemController(kind string, instance Object) {
+	s.systemControllers.Store(kind, &ObjectEntity{super: s, instance: instance})
+}
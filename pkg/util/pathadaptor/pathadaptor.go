@@ -28,10 +28,22 @@ import (
 type (
 	// Spec describes rules for PathAdaptor.
 	Spec struct {
+		// Replace, AddPrefix, TrimPrefix and RegexpReplace are rewrite
+		// rules, only the first specified one of them is applied. AddPrefix
+		// is added to the path as is, e.g. "/v1/" and "/abc" become
+		// "/v1//abc", and "/api-" and "/abc" become "/api-/abc".
 		Replace       string         `json:"replace,omitempty"`
 		AddPrefix     string         `json:"addPrefix,omitempty" jsonschema:"pattern=^/"`
 		TrimPrefix    string         `json:"trimPrefix,omitempty" jsonschema:"pattern=^/"`
 		RegexpReplace *RegexpReplace `json:"regexpReplace,omitempty"`
+
+		// Prepend and Append are applied after the above rules, so they
+		// can be combined with any of them. Different from AddPrefix, they
+		// add a path segment to the beginning or the end of the path, and
+		// make sure there is exactly one slash between the segment and the
+		// path, e.g. Prepend "/v1/" and "/abc" become "/v1/abc".
+		Prepend string `json:"prepend,omitempty" jsonschema:"pattern=^/"`
+		Append  string `json:"append,omitempty"`
 	}
 
 	// RegexpReplace use regexp-replace pair to rewrite path.
@@ -66,6 +78,20 @@ func New(spec *Spec) *PathAdaptor {
 
 // Adapt adapts path.
 func (pa *PathAdaptor) Adapt(path string) string {
+	path = pa.rewrite(path)
+
+	if len(pa.spec.Prepend) != 0 {
+		path = prependSegment(pa.spec.Prepend, path)
+	}
+
+	if len(pa.spec.Append) != 0 {
+		path = appendSegment(path, pa.spec.Append)
+	}
+
+	return path
+}
+
+func (pa *PathAdaptor) rewrite(path string) string {
 	if len(pa.spec.Replace) != 0 {
 		return pa.spec.Replace
 	}
@@ -85,3 +111,28 @@ func (pa *PathAdaptor) Adapt(path string) string {
 
 	return path
 }
+
+// prependSegment adds segment to the beginning of path, the trailing slash
+// of path is kept, so "/" becomes "/segment/".
+func prependSegment(segment, path string) string {
+	segment = strings.TrimRight(segment, "/")
+	if segment == "" {
+		return path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return segment + path
+}
+
+// appendSegment adds segment to the end of path.
+func appendSegment(path, segment string) string {
+	segment = strings.TrimLeft(segment, "/")
+	if segment == "" {
+		return path
+	}
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	return path + segment
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2017, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pathadaptor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdapt(t *testing.T) {
+	assert := assert.New(t)
+
+	pa := New(&Spec{Replace: "/replaced"})
+	assert.Equal("/replaced", pa.Adapt("/abc"))
+
+	pa = New(&Spec{AddPrefix: "/v1"})
+	assert.Equal("/v1/abc", pa.Adapt("/abc"))
+
+	// addPrefix is added as is, while prepend adds a path segment.
+	pa = New(&Spec{AddPrefix: "/v1/"})
+	assert.Equal("/v1//abc", pa.Adapt("/abc"))
+	pa = New(&Spec{AddPrefix: "/api-"})
+	assert.Equal("/api-/abc", pa.Adapt("/abc"))
+
+	pa = New(&Spec{TrimPrefix: "/v1"})
+	assert.Equal("/abc", pa.Adapt("/v1/abc"))
+
+	pa = New(&Spec{RegexpReplace: &RegexpReplace{Regexp: `^/(\w+)/(\w+)$`, Replace: "/$2/$1"}})
+	assert.Equal("/def/abc", pa.Adapt("/abc/def"))
+
+	pa = New(&Spec{})
+	assert.Equal("/abc", pa.Adapt("/abc"))
+}
+
+func TestPrependAndAppend(t *testing.T) {
+	assert := assert.New(t)
+
+	pa := New(&Spec{Prepend: "/internal"})
+	assert.Equal("/internal/abc", pa.Adapt("/abc"))
+	assert.Equal("/internal/", pa.Adapt("/"))
+	assert.Equal("/internal/abc", pa.Adapt("abc"))
+	assert.Equal("/internal/", pa.Adapt(""))
+
+	pa = New(&Spec{Prepend: "/internal/"})
+	assert.Equal("/internal/abc", pa.Adapt("/abc"))
+	assert.Equal("/internal/", pa.Adapt("/"))
+
+	pa = New(&Spec{Append: "v1"})
+	assert.Equal("/abc/v1", pa.Adapt("/abc"))
+	assert.Equal("/abc/v1", pa.Adapt("/abc/"))
+	assert.Equal("/v1", pa.Adapt("/"))
+
+	pa = New(&Spec{Append: "/v1"})
+	assert.Equal("/abc/v1", pa.Adapt("/abc/"))
+	assert.Equal("/v1", pa.Adapt("/"))
+
+	pa = New(&Spec{Prepend: "/", Append: "/"})
+	assert.Equal("/abc", pa.Adapt("/abc"))
+
+	// prepend and append are applied after other rules.
+	pa = New(&Spec{TrimPrefix: "/api", Prepend: "/internal", Append: "detail"})
+	assert.Equal("/internal/users/detail", pa.Adapt("/api/users"))
+	assert.Equal("/internal/detail", pa.Adapt("/api"))
+}